@@ -0,0 +1,218 @@
+package main
+
+import "math"
+
+// Judgement classifies a move by how much it worsened the evaluation
+type Judgement int
+
+const (
+	GoodMove Judgement = iota
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+var judgementNames = [...]string{"good", "inaccuracy", "mistake", "blunder"}
+
+func (j Judgement) String() string {
+	return judgementNames[j]
+}
+
+// NAG returns the annotation of the judgement: ?! for an inaccuracy, ? for a
+// mistake and ?? for a blunder, or 0 for a good move
+func (j Judgement) NAG() int {
+	return [...]int{0, 6, 2, 4}[j]
+}
+
+// Thresholds are the losses from which a move is an inaccuracy, a mistake or
+// a blunder. They are in percentage points of winning chances, or in
+// centipawns when Centipawns is set.
+type Thresholds struct {
+	Inaccuracy, Mistake, Blunder float64
+	Centipawns                   bool
+}
+
+// DefaultThresholds match the Lichess analysis: 5, 10 and 15 points of
+// winning chances
+var DefaultThresholds = Thresholds{Inaccuracy: 5, Mistake: 10, Blunder: 15}
+
+// CentipawnThresholds are the usual thresholds in centipawns
+var CentipawnThresholds = Thresholds{Inaccuracy: 50, Mistake: 100, Blunder: 300, Centipawns: true}
+
+// Helper to judge a move from its losses in centipawns and winning chances
+func (t Thresholds) judge(loss int, winLoss float64) Judgement {
+	x := winLoss
+	if t.Centipawns {
+		x = float64(loss)
+	}
+	switch {
+	case x >= t.Blunder:
+		return Blunder
+	case x >= t.Mistake:
+		return Mistake
+	case x >= t.Inaccuracy:
+		return Inaccuracy
+	}
+	return GoodMove
+}
+
+// Evaluations beyond this many centipawns count as this much, so that mates
+// and hopeless positions do not swamp the average loss
+const maxCentipawns = 1000
+
+// Evaluation of the standard starting position, assumed when the game does
+// not give one before the first move
+var startEval = Eval{Centipawns: 20}
+
+// CappedCentipawns returns the evaluation from White's point of view, mate
+// scores and large advantages being capped
+func (e Eval) CappedCentipawns() int {
+	switch {
+	case e.Mate > 0:
+		return maxCentipawns
+	case e.Mate < 0:
+		return -maxCentipawns
+	}
+	return max(-maxCentipawns, min(e.Centipawns, maxCentipawns))
+}
+
+// WinPercent returns White's winning chances from 0 to 100, with the logistic
+// curve Lichess fitted to its games. A mate for a side counts as a win.
+func (e Eval) WinPercent() float64 {
+	switch {
+	case e.Mate > 0:
+		return 100
+	case e.Mate < 0:
+		return 0
+	}
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(e.CappedCentipawns())))-1)
+}
+
+// MoveAccuracy is the assessment of a main line move
+type MoveAccuracy struct {
+	Ply       int
+	Color     Color
+	SAN       string
+	Before    Eval    // Before the move
+	After     Eval    // After the move
+	Loss      int     // Centipawns lost by the player, capped
+	WinLoss   float64 // Percentage points of winning chances lost
+	Accuracy  float64 // From 0 to 100
+	Judgement Judgement
+}
+
+// PlayerAccuracy summarises the moves of a player
+type PlayerAccuracy struct {
+	Moves        int // Moves with evaluations before and after
+	Inaccuracies int
+	Mistakes     int
+	Blunders     int
+	ACPL         float64 // Average centipawn loss
+	Accuracy     float64 // Average move accuracy
+}
+
+// GameAccuracy is the assessment of the main line of a game
+type GameAccuracy struct {
+	Moves        []MoveAccuracy
+	White, Black PlayerAccuracy
+}
+
+// Player returns the summary of a player
+func (a *GameAccuracy) Player(c Color) *PlayerAccuracy {
+	if c == Black {
+		return &a.Black
+	}
+	return &a.White
+}
+
+// AnalyzeAccuracy compares the %eval of each main line move with the one
+// before it. Moves without both evaluations are skipped, except that a game
+// from the standard start is assumed to begin at +0.20 and a checkmating
+// move needs no evaluation.
+func AnalyzeAccuracy(g *ParsedGame, t Thresholds) *GameAccuracy {
+	a := &GameAccuracy{}
+	before, ok := g.Root.Eval()
+	if _, hasFEN := g.Tag("FEN"); !ok && !hasFEN {
+		before, ok = startEval, true
+	}
+
+	var totalLoss, totalAccuracy [2]int64
+	for i, node := range g.Mainline() {
+		c := node.Parent.Position.Turn()
+		after, hasAfter := node.Eval()
+		if !hasAfter && node.Position.IsCheckmate() {
+			after, hasAfter = Eval{Mate: 1}, true
+			if c == Black {
+				after.Mate = -1
+			}
+		}
+		if ok && hasAfter {
+			m := assessMove(before, after, c, t)
+			m.Ply, m.Color, m.SAN = i+1, c, node.SAN
+			a.Moves = append(a.Moves, m)
+
+			p := a.Player(c)
+			p.Moves++
+			switch m.Judgement {
+			case Inaccuracy:
+				p.Inaccuracies++
+			case Mistake:
+				p.Mistakes++
+			case Blunder:
+				p.Blunders++
+			}
+			totalLoss[c] += int64(m.Loss)
+			totalAccuracy[c] += int64(math.Round(m.Accuracy * 100))
+		}
+		before, ok = after, hasAfter
+	}
+
+	for _, c := range []Color{White, Black} {
+		if p := a.Player(c); p.Moves > 0 {
+			p.ACPL = roundTo(float64(totalLoss[c])/float64(p.Moves), 1)
+			p.Accuracy = roundTo(float64(totalAccuracy[c])/100/float64(p.Moves), 1)
+		}
+	}
+	return a
+}
+
+// Helper to compute the losses of a move from the point of view of the
+// player c who made it
+func assessMove(before, after Eval, c Color, t Thresholds) MoveAccuracy {
+	loss := before.CappedCentipawns() - after.CappedCentipawns()
+	winLoss := before.WinPercent() - after.WinPercent()
+	if c == Black {
+		loss, winLoss = -loss, -winLoss
+	}
+	m := MoveAccuracy{Before: before, After: after, Loss: max(loss, 0), WinLoss: roundTo(max(winLoss, 0), 2)}
+	// Lichess' fit of accuracy to the loss of winning chances
+	m.Accuracy = roundTo(max(0, min(100, 103.1668*math.Exp(-0.04354*m.WinLoss)-3.1669)), 2)
+	m.Judgement = max(t.judge(m.Loss, m.WinLoss), judgeMate(before, after, c))
+	return m
+}
+
+// Helper to judge a move that lets a forced mate slip or walks into one,
+// which winning chances barely register when the position stays lopsided.
+// The less advantage is left, or the less there was, the worse the move.
+func judgeMate(before, after Eval, c Color) Judgement {
+	sign := 1
+	if c == Black {
+		sign = -1
+	}
+	var left int
+	switch {
+	case before.Mate*sign > 0 && after.Mate*sign <= 0:
+		left = after.CappedCentipawns() * sign
+	case before.Mate*sign >= 0 && after.Mate*sign < 0:
+		left = -before.CappedCentipawns() * sign
+	default:
+		return GoodMove
+	}
+	switch {
+	case left >= maxCentipawns:
+		return Inaccuracy
+	case left >= 700:
+		return Mistake
+	}
+	return Blunder
+}
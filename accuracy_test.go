@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const accuracyGame = `[Event "Rapid"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+
+1. e4 {[%eval 0.3]} e5 {[%eval 0.25]} 2. Qh5 {[%eval -0.5]} Nc6 {[%eval -0.5]}
+3. Bc4 {[%eval -0.4]} Nf6 {[%eval 5.0]} 4. Qxf7# 1-0
+`
+
+func TestWinPercent(t *testing.T) {
+	tests := []struct {
+		eval Eval
+		want float64
+	}{
+		{Eval{}, 50},
+		{Eval{Centipawns: 300}, 75.11},
+		{Eval{Centipawns: -300}, 24.89},
+		{Eval{Centipawns: 5000}, 97.55},
+		{Eval{Mate: 3}, 100},
+		{Eval{Mate: -1}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.eval.WinPercent(); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("WinPercent(%v) = %v, expected %v", tt.eval, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeAccuracy(t *testing.T) {
+	g := parseClockGame(t, accuracyGame)
+	for _, tt := range []struct {
+		name       string
+		thresholds Thresholds
+	}{{"winning chances", DefaultThresholds}, {"centipawns", CentipawnThresholds}} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := AnalyzeAccuracy(g, tt.thresholds)
+			if len(a.Moves) != 7 {
+				t.Fatalf("Expected 7 assessed moves, got %d", len(a.Moves))
+			}
+			var judgements []string
+			for _, m := range a.Moves {
+				judgements = append(judgements, m.Judgement.String())
+			}
+			if got, want := strings.Join(judgements, " "), "good good inaccuracy good good blunder good"; got != want {
+				t.Errorf("Expected %q, got %q", want, got)
+			}
+			if a.White.Moves != 4 || a.White.Inaccuracies != 1 || a.White.ACPL != 18.8 {
+				t.Errorf("Unexpected summary for White %+v", a.White)
+			}
+			if a.Black.Moves != 3 || a.Black.Blunders != 1 || a.Black.ACPL != 180 {
+				t.Errorf("Unexpected summary for Black %+v", a.Black)
+			}
+			if a.White.Accuracy <= a.Black.Accuracy || a.White.Accuracy >= 100 {
+				t.Errorf("Unexpected accuracies %v and %v", a.White.Accuracy, a.Black.Accuracy)
+			}
+		})
+	}
+
+	// Letting a forced mate slip is judged by the advantage left
+	for _, tt := range []struct {
+		game string
+		want Judgement
+	}{
+		{"{[%eval #2]} 1. Qb7 {[%eval 12.5]} *", Inaccuracy},
+		{"{[%eval #2]} 1. Qb7 {[%eval 9.5]} *", Mistake},
+		{"{[%eval #2]} 1. Qb7 {[%eval 2.5]} *", Blunder},
+		{"{[%eval 1.5]} 1. Qb7 {[%eval #-3]} *", Blunder},
+		{"{[%eval #2]} 1. Qg7# 1-0", GoodMove},
+	} {
+		g := parseClockGame(t, "[FEN \"7k/Q7/6K1/8/8/8/8/8 w - - 0 1\"]\n\n"+tt.game)
+		if a := AnalyzeAccuracy(g, DefaultThresholds); len(a.Moves) != 1 || a.Moves[0].Judgement != tt.want {
+			t.Errorf("%s: expected %v, got %+v", tt.game, tt.want, a.Moves)
+		}
+	}
+}
+
+func TestAccuracyCommand(t *testing.T) {
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(accuracyGame), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"accuracy", "-moves", "-format", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "file,game,player,color,ply,move,before,after,judgement,loss,win_loss,accuracy\n" +
+		"<stdin>,1,A,white,3,Qh5,0.25,-0.50,inaccuracy,75,6.89,73.26\n" +
+		"<stdin>,1,B,black,6,Nf6,-0.40,5.00,blunder,540,39.98,14.93\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	e.stdin = strings.NewReader(accuracyGame)
+	if err := run(e, []string{"accuracy", "-annotate", "-mistake", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "2. Qh5 $2 ") || !strings.Contains(stdout.String(), "Nf6 $4 ") {
+		t.Errorf("Expected annotated moves in:\n%s", stdout.String())
+	}
+}
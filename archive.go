@@ -0,0 +1,143 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Compression is the format of an input, detected from its first bytes
+type Compression int
+
+const (
+	Uncompressed Compression = iota
+	Gzip
+	Bzip2
+	Zip
+)
+
+var compressionNames = [...]string{"uncompressed", "gzip", "bzip2", "zip"}
+
+func (c Compression) String() string {
+	return compressionNames[c]
+}
+
+// DetectCompression recognises gzip, bzip2 and zip data by their magic bytes
+func DetectCompression(header []byte) Compression {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return Gzip
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return Bzip2
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return Zip
+	}
+	return Uncompressed
+}
+
+// Number of bytes DetectCompression needs
+const compressionHeaderSize = 4
+
+// Helper to detect the compression of an open file from its first bytes
+func fileCompression(f io.ReaderAt) (Compression, error) {
+	header := make([]byte, compressionHeaderSize)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return Uncompressed, err
+	}
+	return DetectCompression(header[:n]), nil
+}
+
+// ReadPGN calls fn with the PGN text of an input: the input itself, its
+// decompressed content for gzip and bzip2, or every .pgn entry of a zip
+// archive with the entry name. The entry is empty for other formats.
+func ReadPGN(r io.Reader, fn func(entry string, r io.Reader) error) error {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(compressionHeaderSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch DetectCompression(header) {
+	case Gzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return fn("", zr)
+	case Bzip2:
+		return fn("", bzip2.NewReader(br))
+	case Zip:
+		return readZip(r, br, fn)
+	}
+	return fn("", br)
+}
+
+// Helper to read the .pgn entries of a zip archive. Archives need random
+// access, so inputs other than regular files are read into memory first.
+func readZip(r io.Reader, br *bufio.Reader, fn func(entry string, r io.Reader) error) error {
+	var ra io.ReaderAt
+	var size int64
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			ra, size = f, info.Size()
+		}
+	}
+	if ra == nil {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return err
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || !strings.EqualFold(path.Ext(file.Name), ".pgn") {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(file.Name, entry)
+		entry.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanGames scans every game of an input read through ReadPGN, calling fn with
+// each game and its number (starting at 1) in the input or archive entry.
+// Games read from a zip archive have their Entry set.
+func ScanGames(r io.Reader, enc Encoding, fn func(n int, game *Game) error) error {
+	return ReadPGN(r, func(entry string, r io.Reader) error {
+		scanner := NewScanner(r)
+		scanner.SetEncoding(enc)
+		for n := 1; ; n++ {
+			game, err := scanner.ScanGame()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			game.Entry = entry
+			if err := fn(n, game); err != nil {
+				return err
+			}
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const archiveGame = "[Event \"E\"]\n[Result \"*\"]\n\n1. e4 *\n"
+
+func gzipData(t *testing.T, text string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(text))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipData(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[i+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectCompression(t *testing.T) {
+	bz2, err := os.ReadFile("testdata/games.pgn.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		data []byte
+		want Compression
+	}{
+		{[]byte(archiveGame), Uncompressed},
+		{gzipData(t, archiveGame), Gzip},
+		{bz2, Bzip2},
+		{zipData(t, "a.pgn", archiveGame), Zip},
+		{zipData(t), Zip},
+		{nil, Uncompressed},
+	}
+	for _, tt := range tests {
+		if got := DetectCompression(tt.data); got != tt.want {
+			t.Errorf("DetectCompression(%q...) = %v, expected %v", tt.data[:min(len(tt.data), 4)], got, tt.want)
+		}
+	}
+}
+
+func TestScanGames(t *testing.T) {
+	bz2, err := os.ReadFile("testdata/games.pgn.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want []string // Entry and event of each game
+	}{
+		{"plain", []byte(archiveGame), []string{":E"}},
+		{"gzip", gzipData(t, archiveGame+"\n"+archiveGame), []string{":E", ":E"}},
+		{"bzip2", bz2, []string{":Bz", ":Bz2"}},
+		{"zip", zipData(t, "a.pgn", archiveGame, "notes.txt", "not a game", "dir/b.PGN", archiveGame), []string{"a.pgn:E", "dir/b.PGN:E"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := ScanGames(bytes.NewReader(tt.data), EncodingAuto, func(n int, game *Game) error {
+				event, _ := ParseSyntax(game.Raw).Games()[0].Tag("Event")
+				got = append(got, game.Entry+":"+event)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if err := ScanGames(bytes.NewReader([]byte{0x1f, 0x8b, 0, 0}), EncodingAuto, func(int, *Game) error { return nil }); err == nil {
+		t.Errorf("Expected an error for corrupt gzip data")
+	}
+}
+
+func TestCompressedInputs(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "games.zip")
+	if err := os.WriteFile(archive, zipData(t, "a.pgn", archiveGame, "b.pgn", archiveGame), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"search", "-moves", "e4", archive}); err != nil {
+		t.Fatal(err)
+	}
+	want := archive + ":a.pgn:1: game 1: ply 1\n" + archive + ":b.pgn:1: game 1: ply 1\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	e.stdin = bytes.NewReader(gzipData(t, archiveGame))
+	if err := run(e, []string{"fmt"}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != archiveGame+"\n" {
+		t.Errorf("Unexpected formatted output %q", stdout.String())
+	}
+
+	if err := run(e, []string{"fmt", "-w", archive}); err == nil {
+		t.Errorf("Expected an error rewriting a compressed file")
+	}
+	if _, err := BuildIndex(archive, nil); err == nil {
+		t.Errorf("Expected an error indexing a compressed file")
+	}
+}
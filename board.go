@@ -0,0 +1,584 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StartFEN is the FEN of the standard starting position
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+type Color int8
+
+const (
+	White Color = iota
+	Black
+)
+
+func (c Color) Other() Color {
+	return 1 - c
+}
+
+func (c Color) String() string {
+	if c == White {
+		return "white"
+	}
+	return "black"
+}
+
+// Square is an index from 0 (a1) to 63 (h8)
+type Square int8
+
+const NoSquare Square = -1
+
+func newSquare(file, rank int) Square {
+	return Square(rank*8 + file)
+}
+
+func (s Square) File() int { return int(s) % 8 }
+func (s Square) Rank() int { return int(s) / 8 }
+
+func (s Square) String() string {
+	if s == NoSquare {
+		return "-"
+	}
+	return string([]byte{byte('a' + s.File()), byte('1' + s.Rank())})
+}
+
+// Helper to parse a square such as "e4"
+func parseSquare(s string) (Square, bool) {
+	if !isSquare(s) {
+		return NoSquare, false
+	}
+	return newSquare(int(s[0]-'a'), int(s[1]-'1')), true
+}
+
+// Move is a move from one square to another. Castling is stored as the king
+// move (e1g1) and Promotion holds the piece letter (Q, R, B, N) if any.
+type Move struct {
+	From      Square
+	To        Square
+	Promotion byte
+}
+
+// UCI returns the move in UCI long algebraic notation (e.g. e2e4, e7e8q)
+func (m Move) UCI() string {
+	s := m.From.String() + m.To.String()
+	if m.Promotion != 0 {
+		s += strings.ToLower(string(m.Promotion))
+	}
+	return s
+}
+
+// Castling rights
+const (
+	castleWhiteKing = 1 << iota
+	castleWhiteQueen
+	castleBlackKing
+	castleBlackQueen
+)
+
+// Position is a chess position. Pieces are stored as FEN letters, upper case
+// for White and lower case for Black, with 0 for empty squares.
+type Position struct {
+	board    [64]byte
+	turn     Color
+	castling int
+	ep       Square // en passant target square
+	halfmove int
+	fullmove int
+}
+
+// NewPosition returns the standard starting position
+func NewPosition() *Position {
+	p, _ := ParseFEN(StartFEN)
+	return p
+}
+
+// ParseFEN parses a position in Forsyth-Edwards Notation
+func ParseFEN(fen string) (*Position, error) {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return nil, ErrInvalidFEN(0)
+	}
+
+	p := &Position{ep: NoSquare, fullmove: 1}
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, ErrInvalidFEN(0)
+	}
+	for i, row := range ranks {
+		file := 0
+		for j := 0; j < len(row); j++ {
+			ch := row[j]
+			switch {
+			case ch >= '1' && ch <= '8':
+				file += int(ch - '0')
+			case strings.IndexByte("PNBRQKpnbrqk", ch) >= 0 && file < 8:
+				p.board[newSquare(file, 7-i)] = ch
+				file++
+			default:
+				return nil, ErrInvalidFEN(j)
+			}
+		}
+		if file != 8 {
+			return nil, ErrInvalidFEN(0)
+		}
+	}
+
+	switch fields[1] {
+	case "w":
+		p.turn = White
+	case "b":
+		p.turn = Black
+	default:
+		return nil, ErrInvalidFEN(0)
+	}
+
+	if fields[2] != "-" {
+		for i := 0; i < len(fields[2]); i++ {
+			switch fields[2][i] {
+			case 'K':
+				p.castling |= castleWhiteKing
+			case 'Q':
+				p.castling |= castleWhiteQueen
+			case 'k':
+				p.castling |= castleBlackKing
+			case 'q':
+				p.castling |= castleBlackQueen
+			default:
+				return nil, ErrInvalidFEN(0)
+			}
+		}
+	}
+
+	if fields[3] != "-" {
+		sq, ok := parseSquare(fields[3])
+		if !ok {
+			return nil, ErrInvalidFEN(0)
+		}
+		p.ep = sq
+	}
+
+	if len(fields) >= 6 {
+		var err error
+		if p.halfmove, err = strconv.Atoi(fields[4]); err != nil {
+			return nil, ErrInvalidFEN(0)
+		}
+		if p.fullmove, err = strconv.Atoi(fields[5]); err != nil {
+			return nil, ErrInvalidFEN(0)
+		}
+	}
+
+	if p.kingSquare(White) == NoSquare || p.kingSquare(Black) == NoSquare {
+		return nil, ErrInvalidFEN(0)
+	}
+
+	return p, nil
+}
+
+// FEN returns the position in Forsyth-Edwards Notation. The en passant square
+// is only written when a capture there is possible.
+func (p *Position) FEN() string {
+	var sb strings.Builder
+	sb.WriteString(p.boardFEN())
+
+	if p.turn == White {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+	sb.WriteString(p.castlingFEN())
+	sb.WriteByte(' ')
+	sb.WriteString(p.epSquare().String())
+	sb.WriteString(" " + strconv.Itoa(p.halfmove) + " " + strconv.Itoa(p.fullmove))
+	return sb.String()
+}
+
+func (p *Position) boardFEN() string {
+	var sb strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			piece := p.board[newSquare(file, rank)]
+			if piece == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(piece)
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	return sb.String()
+}
+
+func (p *Position) castlingFEN() string {
+	s := ""
+	for i, ch := range "KQkq" {
+		if p.castling&(1<<i) != 0 {
+			s += string(ch)
+		}
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// Helper to return the en passant square only if a pawn can capture there
+func (p *Position) epSquare() Square {
+	if p.ep == NoSquare {
+		return NoSquare
+	}
+	pawn, rank := byte('P'), p.ep.Rank()-1
+	if p.turn == Black {
+		pawn, rank = 'p', p.ep.Rank()+1
+	}
+	if rank < 0 || rank > 7 {
+		return NoSquare
+	}
+	for _, df := range []int{-1, 1} {
+		file := p.ep.File() + df
+		if file >= 0 && file < 8 && p.board[newSquare(file, rank)] == pawn {
+			return p.ep
+		}
+	}
+	return NoSquare
+}
+
+// PieceAt returns the FEN letter of the piece on a square, or 0 if it is empty
+func (p *Position) PieceAt(sq Square) byte {
+	return p.board[sq]
+}
+
+// Turn returns the side to move
+func (p *Position) Turn() Color {
+	return p.turn
+}
+
+// Ply returns the number of half moves played since the start of the game,
+// as implied by the full move number
+func (p *Position) Ply() int {
+	return (p.fullmove-1)*2 + int(p.turn)
+}
+
+func pieceColor(piece byte) Color {
+	if piece >= 'a' {
+		return Black
+	}
+	return White
+}
+
+// Helper to return the upper case kind of a piece (P, N, B, R, Q, K)
+func pieceKind(piece byte) byte {
+	if piece >= 'a' {
+		return piece - 'a' + 'A'
+	}
+	return piece
+}
+
+func colored(kind byte, c Color) byte {
+	if c == Black {
+		return kind - 'A' + 'a'
+	}
+	return kind
+}
+
+func (p *Position) kingSquare(c Color) Square {
+	king := colored('K', c)
+	for sq := Square(0); sq < 64; sq++ {
+		if p.board[sq] == king {
+			return sq
+		}
+	}
+	return NoSquare
+}
+
+var (
+	knightSteps   = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps     = [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	bishopSteps   = [][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	rookSteps     = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	promotionKind = []byte{'Q', 'R', 'B', 'N'}
+)
+
+// Helper to step from a square, reporting false when leaving the board
+func shift(sq Square, df, dr int) (Square, bool) {
+	file, rank := sq.File()+df, sq.Rank()+dr
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return NoSquare, false
+	}
+	return newSquare(file, rank), true
+}
+
+// IsAttacked reports whether a square is attacked by a side
+func (p *Position) IsAttacked(sq Square, by Color) bool {
+	pawnRank := -1 // pawns attack forward, so look backwards from the square
+	if by == Black {
+		pawnRank = 1
+	}
+	for _, df := range []int{-1, 1} {
+		if from, ok := shift(sq, df, pawnRank); ok && p.board[from] == colored('P', by) {
+			return true
+		}
+	}
+	for _, step := range knightSteps {
+		if from, ok := shift(sq, step[0], step[1]); ok && p.board[from] == colored('N', by) {
+			return true
+		}
+	}
+	for _, step := range kingSteps {
+		if from, ok := shift(sq, step[0], step[1]); ok && p.board[from] == colored('K', by) {
+			return true
+		}
+	}
+	return p.slidingAttack(sq, by, bishopSteps, 'B') || p.slidingAttack(sq, by, rookSteps, 'R')
+}
+
+func (p *Position) slidingAttack(sq Square, by Color, steps [][2]int, kind byte) bool {
+	for _, step := range steps {
+		for from, ok := shift(sq, step[0], step[1]); ok; from, ok = shift(from, step[0], step[1]) {
+			piece := p.board[from]
+			if piece == 0 {
+				continue
+			}
+			if pieceColor(piece) == by && (pieceKind(piece) == kind || pieceKind(piece) == 'Q') {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// InCheck reports whether the side to move is in check
+func (p *Position) InCheck() bool {
+	king := p.kingSquare(p.turn)
+	return king != NoSquare && p.IsAttacked(king, p.turn.Other())
+}
+
+// IsCheckmate reports whether the side to move is checkmated
+func (p *Position) IsCheckmate() bool {
+	return p.InCheck() && len(p.LegalMoves()) == 0
+}
+
+// IsStalemate reports whether the side to move has no legal move but is not in check
+func (p *Position) IsStalemate() bool {
+	return !p.InCheck() && len(p.LegalMoves()) == 0
+}
+
+// LegalMoves returns all legal moves for the side to move
+func (p *Position) LegalMoves() []Move {
+	var moves []Move
+	for _, m := range p.pseudoMoves() {
+		next := p.Play(m)
+		king := next.kingSquare(p.turn)
+		if king != NoSquare && !next.IsAttacked(king, p.turn.Other()) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// IsLegal reports whether a move is legal in the position
+func (p *Position) IsLegal(m Move) bool {
+	for _, legal := range p.LegalMoves() {
+		if legal == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Position) pseudoMoves() []Move {
+	var moves []Move
+	for from := Square(0); from < 64; from++ {
+		piece := p.board[from]
+		if piece == 0 || pieceColor(piece) != p.turn {
+			continue
+		}
+		switch pieceKind(piece) {
+		case 'P':
+			moves = p.pawnMoves(moves, from)
+		case 'N':
+			moves = p.stepMoves(moves, from, knightSteps)
+		case 'B':
+			moves = p.slideMoves(moves, from, bishopSteps)
+		case 'R':
+			moves = p.slideMoves(moves, from, rookSteps)
+		case 'Q':
+			moves = p.slideMoves(moves, from, bishopSteps)
+			moves = p.slideMoves(moves, from, rookSteps)
+		case 'K':
+			moves = p.stepMoves(moves, from, kingSteps)
+			moves = p.castlingMoves(moves, from)
+		}
+	}
+	return moves
+}
+
+func (p *Position) pawnMoves(moves []Move, from Square) []Move {
+	dir, startRank, lastRank := 1, 1, 7
+	if p.turn == Black {
+		dir, startRank, lastRank = -1, 6, 0
+	}
+
+	add := func(to Square) {
+		if to.Rank() == lastRank {
+			for _, kind := range promotionKind {
+				moves = append(moves, Move{From: from, To: to, Promotion: kind})
+			}
+			return
+		}
+		moves = append(moves, Move{From: from, To: to})
+	}
+
+	if to, ok := shift(from, 0, dir); ok && p.board[to] == 0 {
+		add(to)
+		if from.Rank() == startRank {
+			if to2, ok := shift(to, 0, dir); ok && p.board[to2] == 0 {
+				add(to2)
+			}
+		}
+	}
+	for _, df := range []int{-1, 1} {
+		to, ok := shift(from, df, dir)
+		if !ok {
+			continue
+		}
+		if target := p.board[to]; target != 0 && pieceColor(target) != p.turn || to == p.ep {
+			add(to)
+		}
+	}
+	return moves
+}
+
+func (p *Position) stepMoves(moves []Move, from Square, steps [][2]int) []Move {
+	for _, step := range steps {
+		to, ok := shift(from, step[0], step[1])
+		if !ok {
+			continue
+		}
+		if target := p.board[to]; target == 0 || pieceColor(target) != p.turn {
+			moves = append(moves, Move{From: from, To: to})
+		}
+	}
+	return moves
+}
+
+func (p *Position) slideMoves(moves []Move, from Square, steps [][2]int) []Move {
+	for _, step := range steps {
+		for to, ok := shift(from, step[0], step[1]); ok; to, ok = shift(to, step[0], step[1]) {
+			target := p.board[to]
+			if target == 0 {
+				moves = append(moves, Move{From: from, To: to})
+				continue
+			}
+			if pieceColor(target) != p.turn {
+				moves = append(moves, Move{From: from, To: to})
+			}
+			break
+		}
+	}
+	return moves
+}
+
+func (p *Position) castlingMoves(moves []Move, from Square) []Move {
+	rank, kingSide, queenSide := 0, castleWhiteKing, castleWhiteQueen
+	if p.turn == Black {
+		rank, kingSide, queenSide = 7, castleBlackKing, castleBlackQueen
+	}
+	if from != newSquare(4, rank) || p.InCheck() {
+		return moves
+	}
+
+	rook := colored('R', p.turn)
+	enemy := p.turn.Other()
+	if p.castling&kingSide != 0 && p.board[newSquare(7, rank)] == rook &&
+		p.board[newSquare(5, rank)] == 0 && p.board[newSquare(6, rank)] == 0 &&
+		!p.IsAttacked(newSquare(5, rank), enemy) {
+		moves = append(moves, Move{From: from, To: newSquare(6, rank)})
+	}
+	if p.castling&queenSide != 0 && p.board[newSquare(0, rank)] == rook &&
+		p.board[newSquare(1, rank)] == 0 && p.board[newSquare(2, rank)] == 0 && p.board[newSquare(3, rank)] == 0 &&
+		!p.IsAttacked(newSquare(3, rank), enemy) {
+		moves = append(moves, Move{From: from, To: newSquare(2, rank)})
+	}
+	return moves
+}
+
+// Play returns the position after a move. The move is assumed to be legal.
+func (p *Position) Play(m Move) *Position {
+	next := *p
+	piece := p.board[m.From]
+	kind := pieceKind(piece)
+	captured := p.board[m.To]
+
+	next.board[m.From] = 0
+	next.board[m.To] = piece
+	if m.Promotion != 0 {
+		next.board[m.To] = colored(m.Promotion, p.turn)
+	}
+
+	// En passant capture removes the pawn behind the target square
+	if kind == 'P' && m.To == p.ep && m.From.File() != m.To.File() && captured == 0 {
+		next.board[newSquare(m.To.File(), m.From.Rank())] = 0
+		captured = colored('P', p.turn.Other())
+	}
+
+	// Castling also moves the rook
+	if kind == 'K' && m.To.File()-m.From.File() == 2 {
+		rank := m.From.Rank()
+		next.board[newSquare(5, rank)] = next.board[newSquare(7, rank)]
+		next.board[newSquare(7, rank)] = 0
+	} else if kind == 'K' && m.From.File()-m.To.File() == 2 {
+		rank := m.From.Rank()
+		next.board[newSquare(3, rank)] = next.board[newSquare(0, rank)]
+		next.board[newSquare(0, rank)] = 0
+	}
+
+	next.castling &^= castlingMask(m.From) | castlingMask(m.To)
+
+	next.ep = NoSquare
+	if kind == 'P' && (m.To.Rank()-m.From.Rank() == 2 || m.From.Rank()-m.To.Rank() == 2) {
+		next.ep = newSquare(m.From.File(), (m.From.Rank()+m.To.Rank())/2)
+	}
+
+	next.halfmove++
+	if kind == 'P' || captured != 0 {
+		next.halfmove = 0
+	}
+	if p.turn == Black {
+		next.fullmove++
+	}
+	next.turn = p.turn.Other()
+	return &next
+}
+
+// Helper to return the castling rights lost when a piece leaves or lands on a square
+func castlingMask(sq Square) int {
+	switch sq {
+	case newSquare(4, 0):
+		return castleWhiteKing | castleWhiteQueen
+	case newSquare(7, 0):
+		return castleWhiteKing
+	case newSquare(0, 0):
+		return castleWhiteQueen
+	case newSquare(4, 7):
+		return castleBlackKing | castleBlackQueen
+	case newSquare(7, 7):
+		return castleBlackKing
+	case newSquare(0, 7):
+		return castleBlackQueen
+	}
+	return 0
+}
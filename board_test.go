@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+)
+
+func perft(p *Position, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	nodes := 0
+	for _, m := range p.LegalMoves() {
+		nodes += perft(p.Play(m), depth-1)
+	}
+	return nodes
+}
+
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		depth    int
+		expected int
+	}{
+		{name: "Start position", fen: StartFEN, depth: 3, expected: 8902},
+		{name: "Kiwipete", fen: "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", depth: 2, expected: 2039},
+		{name: "En passant and pins", fen: "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", depth: 4, expected: 43238},
+		{name: "Promotions", fen: "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", depth: 3, expected: 9467},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatalf("Failed to parse FEN: %v", err)
+			}
+			if got := perft(p, tt.depth); got != tt.expected {
+				t.Errorf("Expected %d nodes, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFEN(t *testing.T) {
+	tests := []string{
+		StartFEN,
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"8/8/8/8/8/8/8/K6k w - - 50 80",
+	}
+	for _, fen := range tests {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", fen, err)
+			continue
+		}
+		if got := p.FEN(); got != fen {
+			t.Errorf("Expected %q, got %q", fen, got)
+		}
+	}
+
+	for _, fen := range []string{"", "8/8/8 w - -", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1", "8/8/8/8/8/8/8/8 w - - 0 1"} {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Errorf("Expected error for %q", fen)
+		}
+	}
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		input    string
+		uci      string
+		expected string
+	}{
+		{name: "Pawn push", fen: StartFEN, input: "e4", uci: "e2e4", expected: "e4"},
+		{name: "Long algebraic", fen: StartFEN, input: "Ng1-f3", uci: "g1f3", expected: "Nf3"},
+		{name: "Coordinates", fen: StartFEN, input: "e2e4", uci: "e2e4", expected: "e4"},
+		{name: "Castling with zeros", fen: "3k4/8/8/8/8/8/8/R3K2R w KQ - 0 1", input: "0-0-0", uci: "e1c1", expected: "O-O-O+"},
+		{name: "File disambiguation", fen: "4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", input: "Rad1", uci: "a1d1", expected: "Rad1"},
+		{name: "Rank disambiguation", fen: "4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", input: "R1a3", uci: "a1a3", expected: "R1a3"},
+		{name: "Unneeded disambiguation", fen: StartFEN, input: "Ngf3", uci: "g1f3", expected: "Nf3"},
+		{name: "Promotion without equals", fen: "8/4P3/8/8/8/8/8/K6k w - - 0 1", input: "e8Q", uci: "e7e8q", expected: "e8=Q"},
+		{name: "Underpromotion", fen: "8/4P3/8/8/8/8/8/K6k w - - 0 1", input: "e8=N", uci: "e7e8n", expected: "e8=N"},
+		{name: "En passant", fen: "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", input: "exf6", uci: "e5f6", expected: "exf6"},
+		{name: "Checkmate", fen: "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", input: "Qh4", uci: "d8h4", expected: "Qh4#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatalf("Failed to parse FEN: %v", err)
+			}
+			m, err := p.ParseSAN(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.input, err)
+			}
+			if m.UCI() != tt.uci {
+				t.Errorf("Expected UCI %q, got %q", tt.uci, m.UCI())
+			}
+			if san := p.SAN(m); san != tt.expected {
+				t.Errorf("Expected SAN %q, got %q", tt.expected, san)
+			}
+			if fromUCI, err := p.ParseUCI(tt.uci); err != nil || fromUCI != m {
+				t.Errorf("Expected ParseUCI(%q) to give %v, got %v (%v)", tt.uci, m, fromUCI, err)
+			}
+		})
+	}
+}
+
+func TestSANErrors(t *testing.T) {
+	p, _ := ParseFEN("4k3/8/8/8/8/8/8/R4RK1 w - - 0 1")
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{"Rd1", ErrAmbiguousMove(0)},
+		{"Nf3", ErrIllegalMove(0)},
+		{"e4", ErrIllegalMove(0)},
+		{"O-O-O-O", ErrIllegalMove(0)},
+		{"Rz1", ErrIllegalMove(0)},
+	}
+	for _, tt := range tests {
+		if _, err := p.ParseSAN(tt.input); err == nil || !err.(*PGNError).Is(tt.expected) {
+			t.Errorf("Expected %v for %q, got %v", tt.expected, tt.input, err)
+		}
+	}
+}
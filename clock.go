@@ -0,0 +1,206 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// Phase is a stage of a game, used to split time usage
+type Phase int
+
+const (
+	PhaseOpening Phase = iota
+	PhaseMiddlegame
+	PhaseEndgame
+)
+
+var phaseNames = [...]string{"opening", "middlegame", "endgame"}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+// Last ply of the opening phase, unless the endgame starts earlier
+const openingPlies = 30
+
+// Most non-pawn material of both sides together, in pawns, in an endgame.
+// Each side starts with 31.
+const endgameMaterial = 26
+
+// GamePhase returns the phase of a position reached after a number of plies
+func GamePhase(p *Position, ply int) Phase {
+	m := p.Material()
+	pieces := m.White.Value() + m.Black.Value() - m.White.Count('P') - m.Black.Count('P')
+	switch {
+	case pieces <= endgameMaterial:
+		return PhaseEndgame
+	case ply <= openingPlies:
+		return PhaseOpening
+	}
+	return PhaseMiddlegame
+}
+
+// MoveTime is the clock data of a main line move
+type MoveTime struct {
+	Ply      int
+	Color    Color
+	SAN      string
+	Phase    Phase
+	Clock    time.Duration // Remaining after the move, from %clk
+	HasClock bool
+	Spent    time.Duration // From %emt, or from the clocks and time control
+	HasSpent bool
+}
+
+// PhaseTime is the time a player spent in a phase of the game
+type PhaseTime struct {
+	Moves int
+	Total time.Duration
+}
+
+// SideClock summarises the time usage of a player
+type SideClock struct {
+	Moves        int // Moves whose time spent is known
+	Total        time.Duration
+	Average      time.Duration
+	Longest      time.Duration
+	LongestPly   int
+	TroubleMoves int          // Moves played with less than the threshold left
+	TroublePly   int          // First ply played in time trouble
+	Phases       [3]PhaseTime // By Phase
+	FinalClock   time.Duration
+}
+
+// ClockAnalysis is the time usage of a game
+type ClockAnalysis struct {
+	Moves      []MoveTime
+	White      SideClock
+	Black      SideClock
+	LostOnTime bool
+	Loser      Color // The player who lost on time
+}
+
+// Side returns the summary of a player
+func (a *ClockAnalysis) Side(c Color) *SideClock {
+	if c == Black {
+		return &a.Black
+	}
+	return &a.White
+}
+
+// AnalyzeClock computes the time spent on each main line move from %emt, or
+// else from consecutive %clk values of the same player, adding back the
+// increment and the time of the next period of the TimeControl tag. Moves
+// started with less than trouble on the clock are in time trouble.
+func AnalyzeClock(g *ParsedGame, trouble time.Duration) *ClockAnalysis {
+	a := &ClockAnalysis{}
+	tc, _ := g.TimeControl()
+	if len(tc.Periods) > 0 && tc.Periods[0].Kind == Hourglass {
+		// The clock also depends on the opponent's moves, only %emt is used
+		tc.Periods = nil
+	}
+	var sides [2]struct {
+		clock    time.Duration // Before the next move
+		hasClock bool
+		moves    int // Moves played in the current period
+		period   int
+	}
+	if len(tc.Periods) > 0 {
+		for c := range sides {
+			sides[c].clock, sides[c].hasClock = tc.Periods[0].Time, true
+		}
+	}
+
+	for i, node := range g.Mainline() {
+		c := node.Parent.Position.Turn()
+		side := &sides[c]
+		m := MoveTime{Ply: i + 1, Color: c, SAN: node.SAN, Phase: GamePhase(node.Position, i+1)}
+		m.Clock, m.HasClock = node.Clock()
+
+		// Time added by the time control after this move
+		var bonus time.Duration
+		if side.period < len(tc.Periods) {
+			period := tc.Periods[side.period]
+			bonus = period.Increment
+			side.moves++
+			if period.Kind == MovesInTime && side.moves == period.Moves {
+				side.moves = 0
+				if side.period+1 < len(tc.Periods) {
+					side.period++
+				}
+				bonus += tc.Periods[side.period].Time
+			}
+		}
+
+		stats := a.Side(c)
+		if side.hasClock && side.clock < trouble {
+			stats.TroubleMoves++
+			if stats.TroublePly == 0 {
+				stats.TroublePly = m.Ply
+			}
+		}
+
+		if emt, ok := decodeNodeCommand[time.Duration](node, "emt"); ok {
+			m.Spent, m.HasSpent = emt, true
+		} else if m.HasClock && side.hasClock {
+			m.Spent, m.HasSpent = max(side.clock+bonus-m.Clock, 0), true
+		}
+		switch {
+		case m.HasClock:
+			side.clock, side.hasClock = m.Clock, true
+			stats.FinalClock = m.Clock
+		case side.hasClock && m.HasSpent:
+			side.clock += bonus - m.Spent
+		default:
+			side.hasClock = false
+		}
+		if m.HasSpent {
+			stats.Moves++
+			stats.Total += m.Spent
+			stats.Phases[m.Phase].Moves++
+			stats.Phases[m.Phase].Total += m.Spent
+			if m.Spent > stats.Longest {
+				stats.Longest, stats.LongestPly = m.Spent, m.Ply
+			}
+		}
+		a.Moves = append(a.Moves, m)
+	}
+
+	for _, stats := range []*SideClock{&a.White, &a.Black} {
+		if stats.Moves > 0 {
+			stats.Average = stats.Total / time.Duration(stats.Moves)
+		}
+	}
+	a.Loser, a.LostOnTime = lostOnTime(g, a)
+	return a
+}
+
+// Helper to tell whether a decisive game was lost on time: from a
+// Termination tag such as "Time forfeit", or from the loser's clock reaching
+// zero
+func lostOnTime(g *ParsedGame, a *ClockAnalysis) (Color, bool) {
+	result, _ := g.Tag("Result")
+	if g.Result != "*" {
+		result = g.Result
+	}
+	var loser Color
+	switch result {
+	case "1-0":
+		loser = Black
+	case "0-1":
+		loser = White
+	default:
+		return 0, false
+	}
+
+	termination, _ := g.Tag("Termination")
+	if strings.Contains(strings.ToLower(termination), "time") {
+		return loser, true
+	}
+	for i := len(a.Moves) - 1; i >= 0; i-- {
+		if m := a.Moves[i]; m.Color == loser && m.HasClock {
+			return loser, m.Clock == 0
+		}
+	}
+	return loser, false
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const clockGame = `[Event "Blitz"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+[TimeControl "60+1"]
+
+1. e4 {[%clk 0:01:00]} e5 {[%clk 0:00:58]} 2. Nf3 {[%clk 0:00:50]} Nc6 {[%clk 0:00:20]}
+3. Bb5 {[%emt 0:00:05]} a6 {[%clk 0:00:00]} 1-0
+`
+
+func parseClockGame(t *testing.T, text string) *ParsedGame {
+	t.Helper()
+	g, err := parseGameSyntax(ParseSyntax(text).Games()[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestAnalyzeClock(t *testing.T) {
+	a := AnalyzeClock(parseClockGame(t, clockGame), 30*time.Second)
+
+	var spent []time.Duration
+	for _, m := range a.Moves {
+		spent = append(spent, m.Spent)
+	}
+	want := []time.Duration{1, 3, 11, 39, 5, 21}
+	for i := range want {
+		if spent[i] != want[i]*time.Second {
+			t.Fatalf("Expected times %v (seconds), got %v", want, spent)
+		}
+	}
+
+	if a.White.Total != 17*time.Second || a.White.Longest != 11*time.Second || a.White.LongestPly != 3 || a.White.TroubleMoves != 0 {
+		t.Errorf("Unexpected summary for White %+v", a.White)
+	}
+	if a.Black.Total != 63*time.Second || a.Black.Average != 21*time.Second || a.Black.TroubleMoves != 1 || a.Black.TroublePly != 6 {
+		t.Errorf("Unexpected summary for Black %+v", a.Black)
+	}
+	if a.Black.Phases[PhaseOpening].Moves != 3 || a.Black.Phases[PhaseEndgame].Moves != 0 {
+		t.Errorf("Unexpected phases for Black %+v", a.Black.Phases)
+	}
+	if !a.LostOnTime || a.Loser != Black {
+		t.Errorf("Expected Black to have lost on time")
+	}
+}
+
+func TestAnalyzeClockTimeControls(t *testing.T) {
+	tests := []struct {
+		name  string
+		game  string
+		spent []time.Duration // White's moves, -1 when unknown
+		lost  bool
+	}{
+		{
+			"moves in time",
+			"[TimeControl \"2/60:30\"]\n\n1. e4 {[%clk 0:00:50]} e5 2. Nf3 {[%clk 0:01:10]} Nc6 3. Bb5 {[%clk 0:01:05]} *",
+			[]time.Duration{10, 10, 5}, false,
+		},
+		{
+			"no time control",
+			"[Result \"0-1\"]\n[Termination \"Time forfeit\"]\n\n1. e4 {[%clk 0:05:00]} e5 2. Nf3 {[%clk 0:04:30]} 0-1",
+			[]time.Duration{-1, 30}, true,
+		},
+		{
+			"missing clock",
+			"[TimeControl \"300\"]\n\n1. e4 {[%clk 0:04:50]} e5 2. Nf3 Nc6 3. Bb5 {[%clk 0:04:00]} 1/2-1/2",
+			[]time.Duration{10, -1, -1}, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := AnalyzeClock(parseClockGame(t, tt.game), 0)
+			var got []time.Duration
+			for _, m := range a.Moves {
+				switch {
+				case m.Color == Black:
+				case m.HasSpent:
+					got = append(got, m.Spent/time.Second)
+				default:
+					got = append(got, -1)
+				}
+			}
+			if len(got) != len(tt.spent) {
+				t.Fatalf("Expected %v, got %v", tt.spent, got)
+			}
+			for i := range got {
+				if got[i] != tt.spent[i] {
+					t.Fatalf("Expected %v, got %v", tt.spent, got)
+				}
+			}
+			if a.LostOnTime != tt.lost {
+				t.Errorf("Expected lost on time %v", tt.lost)
+			}
+		})
+	}
+}
+
+func TestGamePhase(t *testing.T) {
+	start := parseClockGame(t, "*").Root.Position
+	if got := GamePhase(start, 0); got != PhaseOpening {
+		t.Errorf("Expected the opening, got %v", got)
+	}
+	if got := GamePhase(start, 40); got != PhaseMiddlegame {
+		t.Errorf("Expected the middlegame, got %v", got)
+	}
+	endgame := parseClockGame(t, "[FEN \"4k3/8/8/8/8/8/4P3/R3K3 w - - 0 1\"]\n\n*").Root.Position
+	if got := GamePhase(endgame, 1); got != PhaseEndgame {
+		t.Errorf("Expected the endgame, got %v", got)
+	}
+}
+
+func TestClockCommand(t *testing.T) {
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(clockGame), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"clock", "-format", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "file,game,player,color,moves,time,average,longest,opening,middlegame,endgame,trouble_moves,first_trouble_ply,final_clock,lost_on_time\n" +
+		"<stdin>,1,A,white,3,0:00:17,0:00:05.7,0:00:11,0:00:17,0:00:00,0:00:00,0,,0:00:50,\n" +
+		"<stdin>,1,B,black,3,0:01:03,0:00:21,0:00:39,0:01:03,0:00:00,0:00:00,1,6,0:00:00,yes\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	e.stdin = strings.NewReader(clockGame + "\n" + strings.Replace(clockGame, "a6 {[%clk 0:00:00]} 1-0", "a6 {[%clk 0:00:10]} 1-0", 1))
+	if err := run(e, []string{"clock", "-flagged", "-moves", "-format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(stdout.String(), `"ply"`); n != 6 {
+		t.Errorf("Expected the 6 moves of the flagged game, got %d in:\n%s", n, stdout.String())
+	}
+}
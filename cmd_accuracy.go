@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"slices"
+)
+
+// accuracyRow is a line of the accuracy report, for a player or a move
+type accuracyRow struct {
+	File         string  `json:"file"`
+	Game         int     `json:"game"`
+	Player       string  `json:"player"`
+	Color        string  `json:"color"`
+	Ply          int     `json:"ply,omitempty"`
+	Move         string  `json:"move,omitempty"`
+	Before       string  `json:"before,omitempty"`
+	After        string  `json:"after,omitempty"`
+	Judgement    string  `json:"judgement,omitempty"`
+	Moves        int     `json:"moves,omitempty"`
+	Inaccuracies int     `json:"inaccuracies"`
+	Mistakes     int     `json:"mistakes"`
+	Blunders     int     `json:"blunders"`
+	ACPL         float64 `json:"acpl"`
+	Loss         int     `json:"loss,omitempty"`
+	WinLoss      float64 `json:"win_loss,omitempty"`
+	Accuracy     float64 `json:"accuracy"`
+}
+
+// runAccuracy reports the average centipawn loss, accuracy and mistakes of
+// each player from the %eval commands of the main line
+func runAccuracy(e *env, args []string) error {
+	fs := newFlagSet(e, "accuracy")
+	inaccuracy := fs.Float64("inaccuracy", DefaultThresholds.Inaccuracy, "loss from which a move is an inaccuracy")
+	mistake := fs.Float64("mistake", DefaultThresholds.Mistake, "loss from which a move is a mistake")
+	blunder := fs.Float64("blunder", DefaultThresholds.Blunder, "loss from which a move is a blunder")
+	centipawns := fs.Bool("cp", false, "measure losses in centipawns instead of winning chances (default thresholds 50, 100 and 300)")
+	moves := fs.Bool("moves", false, "list the inaccuracies, mistakes and blunders")
+	annotate := fs.Bool("annotate", false, "write the games with the NAGs of ?!, ? and ?? added to the moves")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn accuracy [-inaccuracy loss] [-mistake loss] [-blunder loss] [-cp] [-moves | -annotate] [-format table|csv|json] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "csv" && *format != "json" {
+		fs.Usage()
+		return errFailed
+	}
+
+	t := DefaultThresholds
+	if *centipawns {
+		t = CentipawnThresholds
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "inaccuracy":
+			t.Inaccuracy = *inaccuracy
+		case "mistake":
+			t.Mistake = *mistake
+		case "blunder":
+			t.Blunder = *blunder
+		}
+	})
+
+	out := bufio.NewWriter(e.stdout)
+	var rows []accuracyRow
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		a := AnalyzeAccuracy(g, t)
+		switch {
+		case *annotate:
+			annotateMoves(g, a)
+			if err := WritePGN(out, g); err != nil {
+				return err
+			}
+		case *moves:
+			for _, m := range a.Moves {
+				if m.Judgement == GoodMove {
+					continue
+				}
+				player, _ := g.Tag(playerTag(m.Color))
+				rows = append(rows, accuracyRow{File: name, Game: n, Player: player, Color: m.Color.String(),
+					Ply: m.Ply, Move: m.SAN, Before: m.Before.String(), After: m.After.String(), Judgement: m.Judgement.String(),
+					Loss: m.Loss, WinLoss: m.WinLoss, Accuracy: m.Accuracy})
+			}
+		default:
+			for _, c := range []Color{White, Black} {
+				player, _ := g.Tag(playerTag(c))
+				p := a.Player(c)
+				rows = append(rows, accuracyRow{File: name, Game: n, Player: player, Color: c.String(), Moves: p.Moves,
+					Inaccuracies: p.Inaccuracies, Mistakes: p.Mistakes, Blunders: p.Blunders, ACPL: p.ACPL, Accuracy: p.Accuracy})
+			}
+		}
+		return nil
+	})
+
+	if err == nil && !*annotate {
+		if *format == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(rows)
+		} else {
+			header, table := accuracyTable(rows, *moves)
+			if *format == "csv" {
+				err = writeCSV(out, header, table)
+			} else {
+				err = writeTable(out, header, table)
+			}
+		}
+	}
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		err = errFailed
+	}
+	return err
+}
+
+// Helper to add the NAG of each judged move that has no annotation yet
+func annotateMoves(g *ParsedGame, a *GameAccuracy) {
+	mainline := g.Mainline()
+	for _, m := range a.Moves {
+		node := mainline[m.Ply-1]
+		if nag := m.Judgement.NAG(); nag != 0 && !slices.ContainsFunc(node.NAGs, func(n int) bool { return n >= 1 && n <= 6 }) {
+			node.NAGs = append(node.NAGs, nag)
+		}
+	}
+}
+
+// Helper to lay the rows out for table and CSV output
+func accuracyTable(rows []accuracyRow, moves bool) ([]string, [][]string) {
+	var table [][]string
+	if moves {
+		header := []string{"file", "game", "player", "color", "ply", "move", "before", "after", "judgement", "loss", "win_loss", "accuracy"}
+		for _, r := range rows {
+			table = append(table, []string{r.File, itoa(r.Game), r.Player, r.Color, itoa(r.Ply), r.Move, r.Before, r.After,
+				r.Judgement, itoa(r.Loss), ftoa(r.WinLoss), ftoa(r.Accuracy)})
+		}
+		return header, table
+	}
+
+	header := []string{"file", "game", "player", "color", "moves", "inaccuracies", "mistakes", "blunders", "acpl", "accuracy"}
+	for _, r := range rows {
+		table = append(table, []string{r.File, itoa(r.Game), r.Player, r.Color, itoa(r.Moves),
+			itoa(r.Inaccuracies), itoa(r.Mistakes), itoa(r.Blunders), ftoa(r.ACPL), ftoa(r.Accuracy)})
+	}
+	return header, table
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// runAnalyze has a UCI engine evaluate every position of the main line,
+// writing the games back with %eval commands and the engine's best lines
+func runAnalyze(e *env, args []string) error {
+	fs := newFlagSet(e, "analyze")
+	path := fs.String("engine", "", "path of the UCI engine")
+	depth := fs.Int("depth", 0, "search depth per position (18 when -movetime is not set either)")
+	moveTime := fs.Duration("movetime", 0, "search time per position")
+	multiPV := fs.Int("multipv", 1, "number of best lines to search for")
+	plies := fs.Int("lines", 8, "plies of the best lines to add as variations, 0 for none")
+	timeout := fs.Duration("timeout", 0, "stop a search that takes longer than this (movetime plus 10s by default)")
+	var options stringList
+	fs.Var(&options, "option", "engine option as name=value, may be repeated")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn analyze -engine path [-depth n] [-movetime duration] [-multipv n] [-lines plies] [-timeout duration] [-option name=value] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" || *multiPV < 1 || *plies < 0 {
+		fs.Usage()
+		return errFailed
+	}
+	limit := SearchLimit{Depth: *depth, MoveTime: *moveTime, MultiPV: *multiPV, Timeout: *timeout}
+	if limit.Depth == 0 && limit.MoveTime == 0 {
+		limit.Depth = 18
+	}
+
+	engine := NewEngine(*path)
+	if err := engine.Start(); err != nil {
+		return err
+	}
+	defer engine.Close()
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		if err := engine.SetOption(strings.TrimSpace(name), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		if err := engine.AnalyzeGame(g, limit, *plies); err != nil {
+			return fmt.Errorf("%s: game %d: %w", name, n, err)
+		}
+		if err := WritePGN(out, g); err != nil {
+			return err
+		}
+		return nil
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// clockRow is a line of the clock report, for a player or a move
+type clockRow struct {
+	File       string   `json:"file"`
+	Game       int      `json:"game"`
+	Player     string   `json:"player"`
+	Color      string   `json:"color"`
+	Ply        int      `json:"ply,omitempty"`
+	Move       string   `json:"move,omitempty"`
+	Phase      string   `json:"phase,omitempty"`
+	Moves      int      `json:"moves,omitempty"`
+	Time       *float64 `json:"time,omitempty"` // Seconds
+	Average    *float64 `json:"average,omitempty"`
+	Longest    *float64 `json:"longest,omitempty"`
+	Opening    *float64 `json:"opening,omitempty"`
+	Middlegame *float64 `json:"middlegame,omitempty"`
+	Endgame    *float64 `json:"endgame,omitempty"`
+	Trouble    *int     `json:"trouble_moves,omitempty"`
+	TroublePly int      `json:"first_trouble_ply,omitempty"`
+	Clock      *float64 `json:"clock,omitempty"`
+	LostOnTime bool     `json:"lost_on_time,omitempty"`
+}
+
+// runClock reports the time each player spent from %clk and %emt commands,
+// with time trouble and games lost on time, per game or per move
+func runClock(e *env, args []string) error {
+	fs := newFlagSet(e, "clock")
+	trouble := fs.Duration("trouble", 30*time.Second, "clock time under which a player is in time trouble")
+	moves := fs.Bool("moves", false, "report the time spent on each move")
+	flagged := fs.Bool("flagged", false, "only report games lost on time")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn clock [-trouble duration] [-moves] [-flagged] [-format table|csv|json] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "csv" && *format != "json" {
+		fs.Usage()
+		return errFailed
+	}
+
+	var rows []clockRow
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		a := AnalyzeClock(g, *trouble)
+		if *flagged && !a.LostOnTime {
+			return nil
+		}
+		if *moves {
+			rows = append(rows, moveRows(name, n, g, a)...)
+		} else {
+			rows = append(rows, playerClockRows(name, n, g, a)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	if *format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rows)
+	} else {
+		header, table := clockTable(rows, *moves)
+		if *format == "csv" {
+			err = writeCSV(out, header, table)
+		} else {
+			err = writeTable(out, header, table)
+		}
+	}
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		err = errFailed
+	}
+	return err
+}
+
+// Helper to build the rows of both players of a game
+func playerClockRows(name string, n int, g *ParsedGame, a *ClockAnalysis) []clockRow {
+	var rows []clockRow
+	for _, c := range []Color{White, Black} {
+		player, _ := g.Tag(playerTag(c))
+		side := a.Side(c)
+		row := clockRow{
+			File: name, Game: n, Player: player, Color: c.String(),
+			Moves: side.Moves, Trouble: &side.TroubleMoves, TroublePly: side.TroublePly,
+			LostOnTime: a.LostOnTime && a.Loser == c,
+		}
+		if side.Moves > 0 {
+			row.Time, row.Average, row.Longest = seconds(side.Total), seconds(side.Average), seconds(side.Longest)
+			row.Opening = seconds(side.Phases[PhaseOpening].Total)
+			row.Middlegame = seconds(side.Phases[PhaseMiddlegame].Total)
+			row.Endgame = seconds(side.Phases[PhaseEndgame].Total)
+		}
+		for _, m := range a.Moves {
+			if m.Color == c && m.HasClock {
+				row.Clock = seconds(side.FinalClock)
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Helper to build a row per move of a game
+func moveRows(name string, n int, g *ParsedGame, a *ClockAnalysis) []clockRow {
+	var rows []clockRow
+	for _, m := range a.Moves {
+		player, _ := g.Tag(playerTag(m.Color))
+		row := clockRow{File: name, Game: n, Player: player, Color: m.Color.String(), Ply: m.Ply, Move: m.SAN, Phase: m.Phase.String()}
+		if m.HasSpent {
+			row.Time = seconds(m.Spent)
+		}
+		if m.HasClock {
+			row.Clock = seconds(m.Clock)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Helper to name the tag of the player of a colour
+func playerTag(c Color) string {
+	if c == Black {
+		return "Black"
+	}
+	return "White"
+}
+
+func seconds(d time.Duration) *float64 {
+	s := roundTo(d.Seconds(), 1)
+	return &s
+}
+
+// Helper to lay the rows out for table and CSV output, with times as H:MM:SS
+func clockTable(rows []clockRow, moves bool) ([]string, [][]string) {
+	clock := func(s *float64) string {
+		if s == nil {
+			return ""
+		}
+		return FormatClock(time.Duration(*s * float64(time.Second)))
+	}
+	if moves {
+		header := []string{"file", "game", "player", "color", "ply", "move", "phase", "time", "clock"}
+		var table [][]string
+		for _, r := range rows {
+			table = append(table, []string{r.File, itoa(r.Game), r.Player, r.Color, itoa(r.Ply), r.Move, r.Phase, clock(r.Time), clock(r.Clock)})
+		}
+		return header, table
+	}
+
+	header := []string{"file", "game", "player", "color", "moves", "time", "average", "longest",
+		"opening", "middlegame", "endgame", "trouble_moves", "first_trouble_ply", "final_clock", "lost_on_time"}
+	var table [][]string
+	for _, r := range rows {
+		troublePly, lost := "", ""
+		if r.TroublePly > 0 {
+			troublePly = itoa(r.TroublePly)
+		}
+		if r.LostOnTime {
+			lost = "yes"
+		}
+		table = append(table, []string{r.File, itoa(r.Game), r.Player, r.Color, itoa(r.Moves),
+			clock(r.Time), clock(r.Average), clock(r.Longest), clock(r.Opening), clock(r.Middlegame), clock(r.Endgame),
+			itoa(*r.Trouble), troublePly, clock(r.Clock), lost})
+	}
+	return header, table
+}
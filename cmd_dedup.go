@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// runDedup writes the most complete copy of each game, in the order games
+// first appear, or with -report lists the groups of duplicates instead
+func runDedup(e *env, args []string) error {
+	fs := newFlagSet(e, "dedup")
+	tags := fs.String("tags", strings.Join(DefaultDedupTags, ","), "comma-separated tags that must also match, empty for moves only")
+	report := fs.Bool("report", false, "list duplicate groups instead of writing games")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn dedup [-tags keys] [-report] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	d := NewDeduper(splitList(*tags))
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		if err := d.Add(name, n, game); err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	total := 0
+	for _, group := range d.Groups() {
+		total += len(group.Games)
+		if !*report {
+			if _, err := io.WriteString(out, group.Text()+"\n\n"); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(group.Games) == 1 {
+			continue
+		}
+		fmt.Fprintf(out, "%d copies:\n", len(group.Games))
+		for i, ref := range group.Games {
+			kept := ""
+			if i == group.Best {
+				kept = " (kept)"
+			}
+			fmt.Fprintf(out, "  %s:%d: game %d%s\n", ref.File, ref.Line, ref.Number, kept)
+		}
+	}
+	if *report {
+		fmt.Fprintf(out, "%d games, %d unique, %d duplicates removed\n", total, len(d.Groups()), total-len(d.Groups()))
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// runECO writes the games with their ECO, Opening and Variation tags filled
+// from the embedded opening table, leaving the rest of each game untouched.
+// Existing tags are only replaced when the moves contradict the ECO code,
+// unless -keep or -overwrite says otherwise.
+func runECO(e *env, args []string) error {
+	fs := newFlagSet(e, "eco")
+	keep := fs.Bool("keep", false, "only fill missing tags, even when the moves contradict the ECO code")
+	overwrite := fs.Bool("overwrite", false, "replace existing tags with the table's")
+	list := fs.Bool("l", false, "only list the games whose tags would change")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn eco [-keep | -overwrite] [-l] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	policy := correctTags
+	switch {
+	case *keep && *overwrite:
+		fs.Usage()
+		return errFailed
+	case *keep:
+		policy = fillTags
+	case *overwrite:
+		policy = overwriteTags
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		file := ParseSyntax(game.Raw)
+		changed := false
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+		} else if o, ok := ClassifyOpening(g); ok && file.Game() != nil {
+			changed = setOpeningTags(file.Game(), g, o, policy)
+		}
+
+		if *list {
+			if changed {
+				fmt.Fprintf(out, "%s: game %d\n", name, n)
+			}
+			return nil
+		}
+		_, err = io.WriteString(out, file.String()+"\n\n")
+		return err
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// runExplore builds an opening tree from the games and prints the moves played
+// from a position: -fen (the starting position by default) after any -moves
+func runExplore(e *env, args []string) error {
+	fs := newFlagSet(e, "explore")
+	fen := fs.String("fen", "", "position to query")
+	moves := fs.String("moves", "", "moves leading to the position to query, such as \"e4 e5 Nf3\"")
+	depth := fs.Int("depth", 0, "plies of each game to add, 0 for all of them")
+	format := fs.String("format", "table", "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn explore [-fen fen] [-moves moves] [-depth plies] [-format table|json] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		fs.Usage()
+		return errFailed
+	}
+
+	// Check the query before reading the games
+	start := NewPosition()
+	if *fen != "" {
+		p, err := ParseFEN(*fen)
+		if err != nil {
+			return fmt.Errorf("-fen: %w", err)
+		}
+		start = p
+	}
+	position, err := PlayMoves(start, *moves)
+	if err != nil {
+		return fmt.Errorf("-moves: %w", err)
+	}
+
+	x := NewExplorer(*depth)
+	failed := false
+	err = forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		x.AddGame(g)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	result := x.Lookup(position)
+	if *format == "json" {
+		if result == nil {
+			result = []ExplorerMove{}
+		}
+		err = json.NewEncoder(out).Encode(result)
+	} else {
+		var rows [][]string
+		for _, m := range result {
+			rows = append(rows, []string{m.SAN, itoa(m.Games),
+				percent(m.WhiteWins, m.Games), percent(m.Draws, m.Games), percent(m.BlackWins, m.Games), rating(m.AverageElo)})
+		}
+		err = writeTable(out, []string{"move", "games", "white", "draws", "black", "average_elo"}, rows)
+	}
+
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
+
+// Helper to format a count as a percentage of a total
+func percent(n, total int) string {
+	return strconv.FormatFloat(roundTo(100*float64(n)/float64(total), 1), 'f', -1, 64) + "%"
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// runFilter writes the games matching every condition to stdout, unchanged
+// apart from the blank line separating them
+func runFilter(e *env, args []string) error {
+	fs := newFlagSet(e, "filter")
+	var tags stringList
+	fs.Var(&tags, "tag", "tag condition such as White=Carlsen, Event~*Open*, Site=~^Lon, WhiteElo>2400 or Date>=2020 (repeatable)")
+	player := fs.String("player", "", "White or Black matching a shell pattern")
+	elo := fs.Int("elo", 0, "minimum rating of the -player, or of both players")
+	results := fs.String("result", "", "comma-separated results such as 1-0,1/2-1/2")
+	eco := fs.String("eco", "", "ECO code or range such as B20-B99")
+	minPlies := fs.Int("min-plies", 0, "minimum number of plies in the main line")
+	maxPlies := fs.Int("max-plies", -1, "maximum number of plies in the main line")
+	invert := fs.Bool("v", false, "select the games that do not match")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn filter [-tag cond ...] [-player pattern] [-elo n] [-result r] [-eco range] [-min-plies n] [-max-plies n] [-v] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var filters []GameFilter
+	for _, expr := range tags {
+		f, err := ParseTagFilter(expr)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+	}
+	switch {
+	case *player != "":
+		f, err := PlayerFilter(*player, *elo)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+	case *elo > 0:
+		filters = append(filters, EloFilter(*elo))
+	}
+	if *results != "" {
+		f, err := ResultFilter(splitList(*results)...)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+	}
+	if *eco != "" {
+		f, err := ECOFilter(*eco)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+	}
+	if *minPlies > 0 || *maxPlies >= 0 {
+		filters = append(filters, PlyFilter(*minPlies, *maxPlies))
+	}
+	match := AllOf(filters...)
+
+	out := bufio.NewWriter(e.stdout)
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		file := ParseSyntax(game.Raw)
+		if err := trailingError(file); err != nil {
+			// Still filtered and written as is, so that no text is lost
+			reportGameError(e, name, n, game, err)
+			failed = true
+		}
+		node := file.Game()
+		if node == nil || match(node) == *invert {
+			return nil
+		}
+		_, err := io.WriteString(out, game.Raw+"\n\n")
+		return err
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// runFmt rewrites games in export format. Like gofmt, it prints the result by
+// default, lists the files that would change with -l, prints a diff with -d
+// and rewrites the files in place with -w.
+func runFmt(e *env, args []string) error {
+	fs := newFlagSet(e, "fmt")
+	list := fs.Bool("l", false, "list files whose formatting differs")
+	diff := fs.Bool("d", false, "print diffs instead of rewriting files")
+	write := fs.Bool("w", false, "write the result to the files instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn fmt [-l] [-d] [-w] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *write && fs.NArg() == 0 {
+		return errors.New("cannot use -w with standard input")
+	}
+	if *write {
+		for _, path := range fs.Args() {
+			if err := checkUncompressed(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	failed := false
+	err := forEachInput(e, fs.Args(), func(name string, r io.Reader) error {
+		src, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		chunks, err := formatChunks(src)
+		var gameErr *gameError
+		if errors.As(err, &gameErr) {
+			d := NewDiagnostic(gameErr.game, gameErr.err)
+			d.File = name
+			fmt.Fprint(e.stderr, d.Render(wantColor(e.stderr)))
+			failed = true
+			return nil
+		} else if err != nil {
+			fmt.Fprintf(e.stderr, "%s: %v\n", name, err)
+			failed = true
+			return nil
+		}
+
+		var formatted []byte
+		var script []diffLine
+		for _, chunk := range chunks {
+			formatted = append(formatted, chunk.formatted...)
+			script = append(script, diffLines(chunk.src, chunk.formatted)...)
+		}
+		changed := string(formatted) != string(src)
+
+		if *list && changed {
+			fmt.Fprintln(out, name)
+		}
+		if *diff {
+			if err := writeUnifiedDiff(out, name+".orig", name, script); err != nil {
+				return err
+			}
+		}
+		if *write && changed {
+			return writeFileAtomic(name, formatted)
+		}
+		if !*list && !*diff && !*write {
+			_, err = out.Write(formatted)
+		}
+		return err
+	})
+
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
+
+// writeFileAtomic replaces or creates a file through a temporary file in the
+// same directory, keeping the permissions of an existing file
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Helper to refuse rewriting a compressed file as plain text
+func checkUncompressed(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	c, err := fileCompression(f)
+	if err == nil && c != Uncompressed {
+		err = fmt.Errorf("cannot use -w with %s file %s", c, path)
+	}
+	return err
+}
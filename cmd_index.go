@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// runIndex builds or refreshes the index of each file, then prints the games
+// selected by -game or -find by seeking to them directly
+func runIndex(e *env, args []string) error {
+	fs := newFlagSet(e, "index")
+	tags := fs.String("tags", strings.Join(DefaultIndexTags, ","), "comma-separated tags whose values are indexed")
+	game := fs.Int("game", 0, "print the game with this number")
+	find := fs.String("find", "", "print the games whose tag has a value, such as White=Carlsen")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn index [-tags keys] [-game n | -find key=value] file ...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || *game != 0 && *find != "" {
+		fs.Usage()
+		return errFailed
+	}
+
+	keys := splitList(*tags)
+	var findKey, findValue string
+	if *find != "" {
+		var ok bool
+		if findKey, findValue, ok = strings.Cut(*find, "="); !ok || findKey == "" {
+			return fmt.Errorf("invalid -find %q, expected key=value", *find)
+		}
+		if !slices.Contains(keys, findKey) {
+			keys = append(keys, findKey)
+		}
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	for _, path := range fs.Args() {
+		x, rebuilt, err := OpenIndex(path, keys)
+		if err != nil {
+			return err
+		}
+		var games []int
+		switch {
+		case *game != 0:
+			games = []int{*game}
+		case findKey != "":
+			if games, err = x.Find(findKey, findValue); err != nil {
+				return err
+			}
+		default:
+			status := "up to date"
+			if rebuilt {
+				status = "indexed"
+			}
+			fmt.Fprintf(out, "%s: %d games %s\n", path, x.Games(), status)
+			continue
+		}
+		if err := printIndexedGames(out, x, path, games); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// Helper to print games of an indexed file, separated by blank lines
+func printIndexedGames(w io.Writer, x *GameIndex, path string, games []int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, n := range games {
+		game, err := x.ReadGame(f, n)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := io.WriteString(w, game.Raw+"\n\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// runJSON converts PGN games to NDJSON (one JSONGame per line), or NDJSON back
+// to PGN with -r. Games that fail to convert are reported and skipped.
+func runJSON(e *env, args []string) error {
+	fs := newFlagSet(e, "json")
+	reverse := fs.Bool("r", false, "convert NDJSON to PGN")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn json [-r] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	failed := false
+
+	var err error
+	if *reverse {
+		err = forEachInput(e, fs.Args(), func(name string, r io.Reader) error {
+			dec := json.NewDecoder(r)
+			for n := 1; ; n++ {
+				var j JSONGame
+				if err := dec.Decode(&j); err == io.EOF {
+					return nil
+				} else if err != nil {
+					return fmt.Errorf("%s: game %d: %w", name, n, err)
+				}
+				g, err := FromJSON(&j)
+				if err != nil {
+					fmt.Fprintf(e.stderr, "%s: game %d: %v\n", name, n, err)
+					failed = true
+					continue
+				}
+				if err := WritePGN(out, g); err != nil {
+					return err
+				}
+			}
+		})
+	} else {
+		err = forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+			g, err := ParseGame(game)
+			if err != nil {
+				reportGameError(e, name, n, game, err)
+				failed = true
+				return nil
+			}
+			return EncodeJSON(out, g)
+		})
+	}
+
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runLint checks games against the lint rules, printing one diagnostic per
+// line, compiler-style messages with -pretty, or NDJSON with -json. It fails
+// if any error is found.
+func runLint(e *env, args []string) error {
+	fs := newFlagSet(e, "lint")
+	asJSON := fs.Bool("json", false, "print diagnostics as NDJSON")
+	enable := fs.String("enable", "", "comma-separated rules to run instead of all of them")
+	disable := fs.String("disable", "", "comma-separated rules to skip")
+	list := fs.Bool("rules", false, "list the rules and exit")
+	pretty := fs.Bool("pretty", false, "print diagnostics with the source line and hints")
+	colorMode := fs.String("color", "auto", "color -pretty output: auto, always or never")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn lint [-json | -pretty [-color mode]] [-enable rules] [-disable rules] [-rules] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var color bool
+	switch *colorMode {
+	case "auto":
+		color = wantColor(e.stdout)
+	case "always":
+		color = true
+	case "never":
+	default:
+		fs.Usage()
+		return errFailed
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	if *list {
+		for _, rule := range LintRules() {
+			fmt.Fprintf(out, "%-20s %-8s %s\n", rule.ID, rule.Severity, rule.Doc)
+		}
+		return out.Flush()
+	}
+
+	linter := NewLinter()
+	if *enable != "" {
+		if err := linter.Only(splitList(*enable)...); err != nil {
+			return err
+		}
+	}
+	if *disable != "" {
+		if err := linter.Disable(splitList(*disable)...); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		for _, d := range linter.Lint(game) {
+			d.File, d.Game = name, n
+			if d.Severity == SeverityError {
+				failed = true
+			}
+			switch {
+			case *asJSON:
+				if err := enc.Encode(d); err != nil {
+					return err
+				}
+			case *pretty:
+				fmt.Fprintln(out, d.Render(color))
+			default:
+				fmt.Fprintln(out, d)
+			}
+		}
+		return nil
+	})
+
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
+
+// Helper to split a comma-separated flag value, ignoring empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Helper to tell if output goes to a terminal and NO_COLOR is unset, so that
+// diagnostics are colored
+func wantColor(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
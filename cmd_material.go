@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// runMaterial lists the games reaching a material condition, such as a
+// signature or the bishop pair, with the first ply where it held
+func runMaterial(e *env, args []string) error {
+	fs := newFlagSet(e, "material")
+	signature := fs.String("sig", "", "material signature such as KRPvKR, with + for one or more (KRP+vKR)")
+	either := fs.Bool("either", false, "match the signature with either colour")
+	bishopPair := fs.String("bishop-pair", "", "side with the bishop pair against none: white or black")
+	balance := fs.String("balance", "", "range of White's material minus Black's in pawns, such as 2: or -1:1")
+	plies := fs.Int("for", 1, "consecutive plies the conditions must hold for")
+	games := fs.Bool("pgn", false, "write the matching games instead of listing them")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn material [-sig signature] [-either] [-bishop-pair white|black] [-balance range] [-for plies] [-pgn] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var conditions []MaterialCondition
+	if *signature != "" {
+		cond, err := SignatureCondition(*signature, *either)
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, cond)
+	}
+	switch *bishopPair {
+	case "":
+	case "white":
+		conditions = append(conditions, BishopPairCondition(White))
+	case "black":
+		conditions = append(conditions, BishopPairCondition(Black))
+	default:
+		return fmt.Errorf("invalid -bishop-pair %q, expected white or black", *bishopPair)
+	}
+	if *balance != "" {
+		low, high, err := parseRange(*balance)
+		if err != nil {
+			return fmt.Errorf("invalid -balance: %w", err)
+		}
+		conditions = append(conditions, BalanceCondition(low, high))
+	}
+	if len(conditions) == 0 {
+		fs.Usage()
+		return errFailed
+	}
+	cond := AllMaterial(conditions...)
+
+	out := bufio.NewWriter(e.stdout)
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		ply, ok := FindMaterial(g, cond, *plies)
+		if !ok {
+			return nil
+		}
+		if *games {
+			if _, err := io.WriteString(out, game.Raw+"\n\n"); err != nil {
+				return err
+			}
+			return nil
+		}
+		position := g.Root.Position
+		if ply > 0 {
+			position = g.Mainline()[ply-1].Position
+		}
+		ref := NewGameRef(name, n, game)
+		fmt.Fprintf(out, "%s:%d: game %d: ply %d %s\n", ref.File, ref.Line, ref.Number, ply, position.Material().Signature())
+		return nil
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
+
+// Helper to parse an integer range such as 2:5, 2: or :-3, an empty bound
+// being unlimited
+func parseRange(s string) (int, int, error) {
+	lowText, highText, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected a range such as 2:5, got %q", s)
+	}
+	low, high := math.MinInt, math.MaxInt
+	var err error
+	if lowText != "" {
+		if low, err = strconv.Atoi(lowText); err != nil {
+			return 0, 0, fmt.Errorf("invalid bound %q", lowText)
+		}
+	}
+	if highText != "" {
+		if high, err = strconv.Atoi(highText); err != nil {
+			return 0, 0, fmt.Errorf("invalid bound %q", highText)
+		}
+	}
+	if low > high {
+		return 0, 0, fmt.Errorf("empty range %q", s)
+	}
+	return low, high, nil
+}
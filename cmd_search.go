@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// runSearch lists the games reaching a position, by any move order, either
+// by replaying the games or from an index saved with -save-index
+func runSearch(e *env, args []string) error {
+	fs := newFlagSet(e, "search")
+	fen := fs.String("fen", "", "position to search for")
+	moves := fs.String("moves", "", "moves leading to the position, such as \"e4 e5 Nf3\"")
+	index := fs.String("index", "", "search a saved position index instead of the games")
+	save := fs.String("save-index", "", "save the position index of the games to a file")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn search [-fen fen] [-moves moves] [-save-index file] [file ...]")
+		fmt.Fprintln(e.stderr, "       pgn search -index file [-fen fen] [-moves moves]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query := *fen != "" || *moves != ""
+	if !query && *save == "" || *index != "" && (*save != "" || fs.NArg() > 0) {
+		fs.Usage()
+		return errFailed
+	}
+
+	start := NewPosition()
+	if *fen != "" {
+		p, err := ParseFEN(*fen)
+		if err != nil {
+			return fmt.Errorf("-fen: %w", err)
+		}
+		start = p
+	}
+	position, err := PlayMoves(start, *moves)
+	if err != nil {
+		return fmt.Errorf("-moves: %w", err)
+	}
+
+	var x *PositionIndex
+	failed := false
+	if *index != "" {
+		f, err := os.Open(*index)
+		if err != nil {
+			return err
+		}
+		x, err = ReadPositionIndex(bufio.NewReader(f))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *index, err)
+		}
+		// Rebuild the index when the games it was made from changed
+		if err := x.Check(); errors.Is(err, ErrStaleIndex) {
+			if x, failed, err = buildPositionIndex(e, x.Sources()); err != nil {
+				return err
+			}
+			if err := savePositionIndex(*index, x); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", *index, err)
+		}
+	} else {
+		if x, failed, err = buildPositionIndex(e, fs.Args()); err != nil {
+			return err
+		}
+	}
+
+	if *save != "" {
+		if err := savePositionIndex(*save, x); err != nil {
+			return err
+		}
+	}
+
+	if query {
+		out := bufio.NewWriter(e.stdout)
+		for _, m := range x.Search(position) {
+			fmt.Fprintf(out, "%s:%d: game %d: ply %d\n", m.File, m.Line, m.Number, m.Ply)
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// Helper to index the positions of the games of files, or of standard input,
+// reporting whether some of them could not be parsed
+func buildPositionIndex(e *env, paths []string) (*PositionIndex, bool, error) {
+	x := NewPositionIndex()
+	for _, path := range paths {
+		if err := x.AddSource(path); err != nil {
+			return nil, false, err
+		}
+	}
+	failed := false
+	err := forEachGame(e, paths, func(name string, n int, game *Game) error {
+		g, err := ParseGame(game)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		x.Add(NewGameRef(name, n, game), g)
+		return nil
+	})
+	return x, failed, err
+}
+
+// Helper to save a position index to a file
+func savePositionIndex(path string, x *PositionIndex) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	_, err = x.WriteTo(w)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
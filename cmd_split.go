@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// runSplit distributes games over files in a directory
+func runSplit(e *env, args []string) error {
+	fs := newFlagSet(e, "split")
+	count := fs.Int("n", 0, "start a new file every `n` games")
+	by := fs.String("by", "", "split by event, player or year")
+	dir := fs.String("dir", ".", "output directory")
+	template := fs.String("o", "", "file name template with {n}, {player}, {year} or {Tag} placeholders")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn split (-n n | -by event|player|year) [-dir dir] [-o template] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	modes := map[string]SplitMode{"event": SplitEvent, "player": SplitPlayer, "year": SplitYear}
+	var s *Splitter
+	switch mode, ok := modes[*by]; {
+	case *count > 0 && *by == "":
+		s = NewSplitter(*dir, SplitCount, *count)
+	case ok && *count == 0:
+		s = NewSplitter(*dir, mode, 0)
+	default:
+		fs.Usage()
+		return errFailed
+	}
+	s.Template = *template
+
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		return s.Write(game)
+	})
+	if closeErr := s.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// runMerge concatenates the games of several inputs, separated by exactly one
+// blank line
+func runMerge(e *env, args []string) error {
+	fs := newFlagSet(e, "merge")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn merge [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		// The scanner returns each game on its own, without the blank lines around it
+		_, err := io.WriteString(out, game.Raw+"\n\n")
+		return err
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// runStats prints per-player totals, or per-event summaries with -by event,
+// as an aligned table, CSV or JSON
+func runStats(e *env, args []string) error {
+	fs := newFlagSet(e, "stats")
+	by := fs.String("by", "player", "report per player or per event")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn stats [-by player|event] [-format table|csv|json] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *by != "player" && *by != "event" || *format != "table" && *format != "csv" && *format != "json" {
+		fs.Usage()
+		return errFailed
+	}
+
+	stats := NewStats()
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		if node := ParseSyntax(game.Raw).Game(); node != nil {
+			stats.Add(node)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(e.stdout)
+	if *format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if *by == "event" {
+			err = enc.Encode(stats.Events())
+		} else {
+			err = enc.Encode(stats.Players())
+		}
+	} else {
+		var header []string
+		var rows [][]string
+		if *by == "event" {
+			header, rows = eventRows(stats.Events())
+		} else {
+			header, rows = playerRows(stats.Players())
+		}
+		if *format == "csv" {
+			err = writeCSV(out, header, rows)
+		} else {
+			err = writeTable(out, header, rows)
+		}
+	}
+
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func playerRows(players []*PlayerStats) ([]string, [][]string) {
+	header := []string{"player", "games", "wins", "draws", "losses",
+		"white_wins", "white_draws", "white_losses", "black_wins", "black_draws", "black_losses",
+		"score", "score_percent", "average_opponent_elo", "performance"}
+	var rows [][]string
+	for _, p := range players {
+		rows = append(rows, []string{p.Name, itoa(p.Games), itoa(p.Wins()), itoa(p.Draws()), itoa(p.Losses()),
+			itoa(p.White.Wins), itoa(p.White.Draws), itoa(p.White.Losses),
+			itoa(p.Black.Wins), itoa(p.Black.Draws), itoa(p.Black.Losses),
+			ftoa(p.Score), ftoa(p.ScorePercent), rating(p.AverageOpponentElo), rating(float64(p.Performance))})
+	}
+	return header, rows
+}
+
+func eventRows(events []*EventStats) ([]string, [][]string) {
+	header := []string{"event", "games", "white_wins", "draws", "black_wins", "unfinished", "players", "average_elo", "start", "end"}
+	var rows [][]string
+	for _, ev := range events {
+		rows = append(rows, []string{ev.Name, itoa(ev.Games), itoa(ev.WhiteWins), itoa(ev.Draws), itoa(ev.BlackWins),
+			itoa(ev.Unfinished), itoa(ev.Players), rating(ev.AverageElo), ev.Start, ev.End})
+	}
+	return header, rows
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+func ftoa(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+// Helper to format a rating, leaving it empty when unknown (zero)
+func rating(x float64) string {
+	if x == 0 {
+		return ""
+	}
+	return ftoa(x)
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	return cw.WriteAll(rows)
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]string{header}, rows...) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// runStrip anonymises games for publishing, replacing player names by
+// pseudonyms and dropping ratings, site and date, and optionally comments,
+// variations, clock and eval commands
+func runStrip(e *env, args []string) error {
+	fs := newFlagSet(e, "strip")
+	opts := DefaultStripOptions
+	fs.BoolVar(&opts.Names, "names", opts.Names, "replace player names by pseudonyms, dropping titles, FIDE IDs and teams")
+	fs.BoolVar(&opts.Ratings, "ratings", opts.Ratings, "drop ratings")
+	fs.BoolVar(&opts.Site, "site", opts.Site, "blank the Site tag")
+	fs.BoolVar(&opts.Date, "date", opts.Date, "blank the Date tag, dropping the other dates and times")
+	fs.BoolVar(&opts.Comments, "comments", false, "drop comment text")
+	fs.BoolVar(&opts.Variations, "variations", false, "drop variations")
+	fs.BoolVar(&opts.Clocks, "clocks", false, "drop %clk, %emt, %egt and %mct commands")
+	fs.BoolVar(&opts.Evals, "evals", false, "drop %eval commands")
+	tags := fs.String("tags", "", "comma-separated further tags to drop")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: pgn strip [-names=false] [-ratings=false] [-site=false] [-date=false] [-comments] [-variations] [-clocks] [-evals] [-tags list] [file ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts.Tags = splitList(*tags)
+
+	out := bufio.NewWriter(e.stdout)
+	names := &Pseudonyms{}
+	failed := false
+	err := forEachGame(e, fs.Args(), func(name string, n int, game *Game) error {
+		stripped, err := StripGame(game, opts, names)
+		if err != nil {
+			reportGameError(e, name, n, game, err)
+			failed = true
+			return nil
+		}
+		_, err = io.WriteString(out, stripped)
+		return err
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && failed {
+		return errFailed
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommandDecoder turns the parameters of an embedded command into a typed value
+type CommandDecoder func(params []string) (any, error)
+
+var commandDecoders = map[string]CommandDecoder{
+	"clk":  decodeDuration, // Remaining clock time
+	"emt":  decodeDuration, // Elapsed move time
+	"egt":  decodeDuration, // Elapsed game time
+	"mct":  decodeDuration, // Mechanical clock time
+	"eval": decodeEval,
+	"csl":  decodeColoredSquares, // Coloured squares
+	"cal":  decodeArrows,         // Coloured arrows
+}
+
+// RegisterCommand registers the decoder used for a command name, replacing
+// any previous one
+func RegisterCommand(name string, decoder CommandDecoder) {
+	commandDecoders[name] = decoder
+}
+
+// Decode returns the typed value of a command using its registered decoder.
+// Errors are PGNErrors located at the command.
+func (c Command) Decode() (any, error) {
+	decoder, ok := commandDecoders[c.Name]
+	if !ok {
+		return nil, ErrUnknownCommand(c.Pos)
+	}
+	value, err := decoder(c.Params)
+	if err == nil {
+		return value, nil
+	}
+	if _, ok := err.(*PGNError); ok {
+		return nil, withPos(err, c.Pos)
+	}
+	return nil, &PGNError{msg: fmt.Sprintf("invalid %%%s command: %v", c.Name, err), pos: c.Pos}
+}
+
+// Command returns the first command of a node with the given name
+func (n *MoveNode) Command(name string) (Command, bool) {
+	for _, cmd := range n.Commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// SetCommand replaces the first command of a node with the same name, or
+// appends it if there is none
+func (n *MoveNode) SetCommand(cmd Command) {
+	for i := range n.Commands {
+		if n.Commands[i].Name == cmd.Name {
+			n.Commands[i] = cmd
+			return
+		}
+	}
+	n.Commands = append(n.Commands, cmd)
+}
+
+// Clock returns the remaining clock time from the %clk command of a node
+func (n *MoveNode) Clock() (time.Duration, bool) {
+	return decodeNodeCommand[time.Duration](n, "clk")
+}
+
+// Eval returns the evaluation from the %eval command of a node
+func (n *MoveNode) Eval() (Eval, bool) {
+	return decodeNodeCommand[Eval](n, "eval")
+}
+
+// Helper to decode a command of a node, ignoring malformed values
+func decodeNodeCommand[T any](n *MoveNode, name string) (T, bool) {
+	var zero T
+	cmd, ok := n.Command(name)
+	if !ok {
+		return zero, false
+	}
+	value, err := cmd.Decode()
+	if err != nil {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// decodeDuration parses times such as 1:05:00, 0:00:07.3, 5:00 or 12.5
+func decodeDuration(params []string) (any, error) {
+	if len(params) != 1 {
+		return nil, ErrInvalidCommandParam(0)
+	}
+	return parseClock(params[0])
+}
+
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 || strings.Trim(s, "0123456789:.") != "" {
+		return 0, ErrInvalidCommandParam(0)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || len(parts) > 1 && seconds >= 60 {
+		return 0, ErrInvalidCommandParam(0)
+	}
+	total := time.Duration(math.Round(seconds * float64(time.Second)))
+
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || i > 0 && n >= 60 {
+			return 0, ErrInvalidCommandParam(0)
+		}
+		total += time.Duration(n) * unit
+		unit *= 60
+	}
+	return total, nil
+}
+
+// FormatClock formats a duration the way %clk and %emt expect it (H:MM:SS),
+// adding tenths of a second when needed
+func FormatClock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	hours := d / time.Hour
+	minutes := d % time.Hour / time.Minute
+	seconds := d % time.Minute / time.Second
+	s := fmt.Sprintf("%s%d:%02d:%02d", sign, hours, minutes, seconds)
+	if tenths := d % time.Second / (100 * time.Millisecond); tenths != 0 {
+		s += "." + strconv.Itoa(int(tenths))
+	}
+	return s
+}
+
+// Eval is an engine evaluation from White's point of view
+type Eval struct {
+	Centipawns int // Score in hundredths of a pawn, unused for mate scores
+	Mate       int // Moves to mate, negative when Black mates, 0 if not a mate score
+	Depth      int // Search depth, 0 if unknown
+}
+
+// IsMate reports whether the evaluation is a forced mate
+func (e Eval) IsMate() bool {
+	return e.Mate != 0
+}
+
+// String formats the evaluation as the parameters of an %eval command
+func (e Eval) String() string {
+	var s string
+	if e.IsMate() {
+		s = "#" + strconv.Itoa(e.Mate)
+	} else {
+		s = strconv.FormatFloat(float64(e.Centipawns)/100, 'f', 2, 64)
+	}
+	if e.Depth > 0 {
+		s += "," + strconv.Itoa(e.Depth)
+	}
+	return s
+}
+
+// decodeEval parses evaluations such as 0.35, -6.05, #-3 or 0.17,23 (with depth)
+func decodeEval(params []string) (any, error) {
+	if len(params) == 0 || len(params) > 2 {
+		return nil, ErrInvalidCommandParam(0)
+	}
+
+	var e Eval
+	score := params[0]
+	if mate, ok := strings.CutPrefix(score, "#"); ok {
+		n, err := strconv.Atoi(mate)
+		if err != nil || n == 0 {
+			return nil, ErrInvalidCommandParam(0)
+		}
+		e.Mate = n
+	} else {
+		pawns, err := strconv.ParseFloat(score, 64)
+		if err != nil || math.IsInf(pawns, 0) || math.IsNaN(pawns) {
+			return nil, ErrInvalidCommandParam(0)
+		}
+		e.Centipawns = int(math.Round(pawns * 100))
+	}
+
+	if len(params) == 2 {
+		depth, err := strconv.Atoi(params[1])
+		if err != nil || depth < 0 {
+			return nil, ErrInvalidCommandParam(0)
+		}
+		e.Depth = depth
+	}
+	return e, nil
+}
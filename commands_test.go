@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeDurationCommands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"0:05:00", 5 * time.Minute},
+		{"1:59:50", time.Hour + 59*time.Minute + 50*time.Second},
+		{"0:00:07.3", 7300 * time.Millisecond},
+		{"12:34", 12*time.Minute + 34*time.Second},
+		{"2.75", 2750 * time.Millisecond},
+		{"27:00:00", 27 * time.Hour},
+	}
+	for _, name := range []string{"clk", "emt", "egt", "mct"} {
+		for _, tt := range tests {
+			value, err := Command{Name: name, Params: []string{tt.input}}.Decode()
+			if err != nil {
+				t.Errorf("%%%s %s - unexpected error %v", name, tt.input, err)
+				continue
+			}
+			if value != tt.expected {
+				t.Errorf("%%%s %s - Expected %v, got %v", name, tt.input, tt.expected, value)
+			}
+		}
+	}
+
+	for _, input := range []string{"", "1:2:3:4", "0:61:00", "0:00:75", "a:00", "-0:05:00", "1.5:00"} {
+		if _, err := (Command{Name: "clk", Params: []string{input}, Pos: 12}).Decode(); !errors.Is(err, ErrInvalidCommandParam(0)) || err.(*PGNError).Pos() != 12 {
+			t.Errorf("Expected positioned invalid parameter error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{5 * time.Minute, "0:05:00"},
+		{time.Hour + 59*time.Minute + 50*time.Second, "1:59:50"},
+		{7300 * time.Millisecond, "0:00:07.3"},
+	}
+	for _, tt := range tests {
+		if got := FormatClock(tt.input); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestDecodeEval(t *testing.T) {
+	tests := []struct {
+		params   []string
+		expected Eval
+		str      string
+	}{
+		{[]string{"0.35"}, Eval{Centipawns: 35}, "0.35"},
+		{[]string{"-6.05"}, Eval{Centipawns: -605}, "-6.05"},
+		{[]string{"+1.2"}, Eval{Centipawns: 120}, "1.20"},
+		{[]string{"#4"}, Eval{Mate: 4}, "#4"},
+		{[]string{"#-3"}, Eval{Mate: -3}, "#-3"},
+		{[]string{"0.17", "23"}, Eval{Centipawns: 17, Depth: 23}, "0.17,23"},
+	}
+	for _, tt := range tests {
+		value, err := Command{Name: "eval", Params: tt.params}.Decode()
+		if err != nil {
+			t.Errorf("%v - unexpected error %v", tt.params, err)
+			continue
+		}
+		if value != tt.expected {
+			t.Errorf("%v - Expected %+v, got %+v", tt.params, tt.expected, value)
+		}
+		if got := value.(Eval).String(); got != tt.str {
+			t.Errorf("%v - Expected string %q, got %q", tt.params, tt.str, got)
+		}
+	}
+
+	for _, params := range [][]string{{}, {"abc"}, {"#0"}, {"#x"}, {"1.0", "deep"}, {"1", "2", "3"}} {
+		if _, err := (Command{Name: "eval", Params: params}).Decode(); !errors.Is(err, ErrInvalidCommandParam(0)) {
+			t.Errorf("Expected invalid parameter error for %v, got %v", params, err)
+		}
+	}
+}
+
+func TestCustomCommand(t *testing.T) {
+	if _, err := (Command{Name: "tmp", Pos: 3}).Decode(); !errors.Is(err, ErrUnknownCommand(0)) {
+		t.Errorf("Expected unknown command error, got %v", err)
+	}
+
+	RegisterCommand("tmp", func(params []string) (any, error) {
+		if len(params) == 0 {
+			return nil, errors.New("missing value")
+		}
+		return len(params), nil
+	})
+	defer delete(commandDecoders, "tmp")
+
+	if value, err := (Command{Name: "tmp", Params: []string{"a", "b"}}).Decode(); err != nil || value != 2 {
+		t.Errorf("Expected 2, got %v (%v)", value, err)
+	}
+	_, err := Command{Name: "tmp", Pos: 3}.Decode()
+	if pgnErr, ok := err.(*PGNError); !ok || pgnErr.Pos() != 3 || pgnErr.Error() != "invalid %tmp command: missing value" {
+		t.Errorf("Expected a positioned error, got %v", err)
+	}
+}
+
+func TestMoveNodeCommands(t *testing.T) {
+	g, err := ParseGame(&Game{Raw: "1. e4 {[%eval 0.3] [%clk 0:03:00]} e5 {[%clk bad]} *"})
+	if err != nil {
+		t.Fatalf("Failed to parse game: %v", err)
+	}
+	mainline := g.Mainline()
+	if clk, ok := mainline[0].Clock(); !ok || clk != 3*time.Minute {
+		t.Errorf("Expected clock 3m, got %v", clk)
+	}
+	if eval, ok := mainline[0].Eval(); !ok || eval.Centipawns != 30 {
+		t.Errorf("Expected eval 30, got %v", eval)
+	}
+	if _, ok := mainline[1].Clock(); ok {
+		t.Errorf("Expected malformed clock to be ignored")
+	}
+	if _, err := mainline[1].Commands[0].Decode(); err == nil || err.(*PGNError).Pos() != 39 {
+		t.Errorf("Expected error at the command position, got %v", err)
+	}
+}
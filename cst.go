@@ -0,0 +1,400 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// SyntaxKind identifies the kind of a node in the concrete syntax tree
+type SyntaxKind int
+
+const (
+	SyntaxFile      SyntaxKind = iota // A whole PGN input, children are games, comments no game follows and an EOF leaf
+	SyntaxGame                        // One game, from its first tag to its result and any comments after it
+	SyntaxTag                         // [Key "Value"]
+	SyntaxMove                        // The tokens making up one move (e.g. N, b, x, d5, +)
+	SyntaxComment                     // { ... } including any commands inside
+	SyntaxCommand                     // [%name params]
+	SyntaxVariation                   // ( ... )
+	SyntaxToken                       // A leaf holding a single token
+)
+
+// SyntaxNode is a node of the concrete syntax tree. Leaves (SyntaxToken) hold
+// a token, its exact source text and the trivia that preceded it, so printing
+// the tree reproduces the input byte for byte.
+type SyntaxNode struct {
+	Kind     SyntaxKind
+	Children []*SyntaxNode
+
+	Token   Token  // leaf only
+	Leading string // leaf only, whitespace skipped before the token
+	Raw     string // leaf only, source text of the token (e.g. `"Value"` with quotes)
+}
+
+// ParseSyntax builds a lossless concrete syntax tree from a PGN input.
+// Input the lexer cannot make sense of is kept verbatim in the final EOF leaf.
+func ParseSyntax(input string) *SyntaxNode {
+	b := &syntaxBuilder{file: &SyntaxNode{Kind: SyntaxFile}}
+	lexer := NewLexer(input)
+	prevEnd := 0
+
+	for {
+		tok := lexer.NextToken()
+		if tok.Type == EOF {
+			// End of input, or an error the lexer cannot continue from. Comments
+			// that no game followed stay outside any game.
+			b.file.Children = append(b.file.Children, b.pending...)
+			b.file.Children = append(b.file.Children, &SyntaxNode{
+				Kind:    SyntaxToken,
+				Token:   Token{Type: EOF, Error: tok.Error, Pos: prevEnd, End: len(input)},
+				Leading: input[prevEnd:skipTrivia(input, prevEnd)],
+				Raw:     input[skipTrivia(input, prevEnd):],
+			})
+			return b.file
+		}
+
+		b.add(&SyntaxNode{
+			Kind:    SyntaxToken,
+			Token:   tok,
+			Leading: input[prevEnd:tok.Pos],
+			Raw:     input[tok.Pos:tok.End],
+		})
+		prevEnd = tok.End
+	}
+}
+
+// Helper to skip whitespace starting at pos
+func skipTrivia(input string, pos int) int {
+	for pos < len(input) && isWhitespace(input[pos]) {
+		pos++
+	}
+	return pos
+}
+
+type syntaxBuilder struct {
+	file    *SyntaxNode
+	stack   []*SyntaxNode // open nodes, the game is always at the bottom once one started
+	pending []*SyntaxNode // comments before the first game, which starts with them
+	move    *SyntaxNode   // move currently being assembled, if any
+	done    bool          // whether the current move already has its destination
+	ended   bool          // whether the game at the bottom has its result
+}
+
+func (b *syntaxBuilder) top() *SyntaxNode {
+	if len(b.stack) == 0 {
+		game := &SyntaxNode{Kind: SyntaxGame, Children: b.pending}
+		b.pending = nil
+		b.file.Children = append(b.file.Children, game)
+		b.stack = append(b.stack, game)
+	}
+	return b.stack[len(b.stack)-1]
+}
+
+func (b *syntaxBuilder) open(kind SyntaxKind, leaf *SyntaxNode) {
+	node := &SyntaxNode{Kind: kind, Children: []*SyntaxNode{leaf}}
+	parent := b.top()
+	parent.Children = append(parent.Children, node)
+	b.stack = append(b.stack, node)
+}
+
+// Helper to close the innermost open node of a kind, along with anything left
+// open inside it. A stray closing token is kept in the current node.
+func (b *syntaxBuilder) close(kind SyntaxKind, leaf *SyntaxNode) {
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		if b.stack[i].Kind == kind {
+			b.stack[i].Children = append(b.stack[i].Children, leaf)
+			b.stack = b.stack[:i]
+			return
+		}
+	}
+	node := b.top()
+	node.Children = append(node.Children, leaf)
+}
+
+func (b *syntaxBuilder) add(leaf *SyntaxNode) {
+	if !isMoveToken(leaf.Token.Type) {
+		if leaf.Token.Type == ILLEGAL && b.continuesMove(leaf) {
+			// Unreadable text right after an unfinished move, such as the
+			// square of Bz5, is part of it
+			b.move.Children = append(b.move.Children, leaf)
+			b.move = nil
+			return
+		}
+		b.move = nil
+	}
+	if len(b.stack) == 0 && leaf.Token.Type == COMMENT_START {
+		// A comment before any game is held until one starts, so that comments
+		// alone never make a game
+		node := &SyntaxNode{Kind: SyntaxComment, Children: []*SyntaxNode{leaf}}
+		b.pending = append(b.pending, node)
+		b.stack = append(b.stack, node)
+		return
+	}
+	if b.ended && len(b.stack) == 1 && leaf.Token.Type != COMMENT_START {
+		// Comments after the result stay with the game, anything else starts
+		// a new one
+		b.stack, b.ended = nil, false
+	}
+
+	switch leaf.Token.Type {
+	case TAG_START:
+		b.open(SyntaxTag, leaf)
+	case TAG_END:
+		b.close(SyntaxTag, leaf)
+	case COMMENT_START:
+		b.open(SyntaxComment, leaf)
+	case COMMENT_END:
+		b.close(SyntaxComment, leaf)
+	case COMMAND_START:
+		b.open(SyntaxCommand, leaf)
+	case COMMAND_END:
+		b.close(SyntaxCommand, leaf)
+	case VARIATION_START:
+		b.open(SyntaxVariation, leaf)
+	case VARIATION_END:
+		b.close(SyntaxVariation, leaf)
+	case RESULT:
+		parent := b.top()
+		parent.Children = append(parent.Children, leaf)
+		b.ended = parent.Kind == SyntaxGame
+	default:
+		if isMoveToken(leaf.Token.Type) {
+			b.addMoveToken(leaf)
+			return
+		}
+		parent := b.top()
+		parent.Children = append(parent.Children, leaf)
+	}
+}
+
+// Helper to group consecutive move tokens into SyntaxMove nodes. A move ends
+// with its destination square (or castling), after which only promotion and
+// check markers still belong to it.
+func (b *syntaxBuilder) addMoveToken(leaf *SyntaxNode) {
+	typ := leaf.Token.Type
+	continues := false
+	if b.move != nil {
+		last := b.move.Children[len(b.move.Children)-1].Token.Type
+		switch typ {
+		case FILE, RANK, SQUARE:
+			continues = !b.done
+		case CAPTURE:
+			// Either before the destination, or after a full from-square (Qa1xb2)
+			continues = !b.done || last == SQUARE && len(b.move.Children) > 1 && b.move.Children[0].Token.Type == PIECE
+		case PROMOTION, PROMOTION_PIECE, CHECK, CHECKMATE:
+			continues = true
+		}
+	}
+
+	if !continues {
+		b.move = &SyntaxNode{Kind: SyntaxMove}
+		parent := b.top()
+		parent.Children = append(parent.Children, b.move)
+		b.done = false
+	}
+	b.move.Children = append(b.move.Children, leaf)
+
+	switch typ {
+	case SQUARE, KINGSIDE_CASTLE, QUEENSIDE_CASTLE:
+		b.done = true
+	case CAPTURE:
+		b.done = false
+	}
+}
+
+// Helper to check whether a token directly follows the move being built,
+// before its destination square
+func (b *syntaxBuilder) continuesMove(leaf *SyntaxNode) bool {
+	if b.move == nil || b.done {
+		return false
+	}
+	last := b.move.Children[len(b.move.Children)-1].Token
+	return leaf.Token.Pos == last.Pos+len(last.Value)
+}
+
+// Helper to check whether a token type can be part of a move
+func isMoveToken(t TokenType) bool {
+	switch t {
+	case PIECE, FILE, RANK, SQUARE, CAPTURE, PROMOTION, PROMOTION_PIECE,
+		CHECK, CHECKMATE, KINGSIDE_CASTLE, QUEENSIDE_CASTLE:
+		return true
+	}
+	return false
+}
+
+// String prints the node exactly as it appeared in the source (plus any edits)
+func (n *SyntaxNode) String() string {
+	var sb strings.Builder
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+// WriteTo writes the source text of the node to w
+func (n *SyntaxNode) WriteTo(w io.Writer) (int64, error) {
+	written, err := io.WriteString(w, n.String())
+	return int64(written), err
+}
+
+func (n *SyntaxNode) writeTo(sb *strings.Builder) {
+	if n.Kind == SyntaxToken {
+		sb.WriteString(n.Leading)
+		sb.WriteString(n.Raw)
+		return
+	}
+	for _, child := range n.Children {
+		child.writeTo(sb)
+	}
+}
+
+// Walk visits n and its descendants depth first, skipping the children of a
+// node when fn returns false
+func (n *SyntaxNode) Walk(fn func(*SyntaxNode) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
+
+// Games returns the game nodes of a file node
+func (n *SyntaxNode) Games() []*SyntaxNode {
+	var games []*SyntaxNode
+	for _, child := range n.Children {
+		if child.Kind == SyntaxGame {
+			games = append(games, child)
+		}
+	}
+	return games
+}
+
+// Game returns the first game node of a file node, or nil if it has none. A
+// scanned game holds a single one.
+func (n *SyntaxNode) Game() *SyntaxNode {
+	if games := n.Games(); len(games) > 0 {
+		return games[0]
+	}
+	return nil
+}
+
+// Tag returns the value of a tag in a game node
+func (n *SyntaxNode) Tag(key string) (string, bool) {
+	if tag := n.findTag(key); tag != nil {
+		if value := tag.leaf(TAG_VALUE); value != nil {
+			return value.Token.Value, true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// SetTag changes the value of a tag in a game node, leaving every other byte
+// of the game untouched. Missing tags are added after the last existing one.
+func (n *SyntaxNode) SetTag(key, value string) {
+	raw := `"` + tagValueEscaper.Replace(value) + `"`
+
+	if tag := n.findTag(key); tag != nil {
+		if leaf := tag.leaf(TAG_VALUE); leaf != nil {
+			leaf.Token.Value = value
+			leaf.Raw = raw
+			return
+		}
+		// [Key] without a value, insert one before the closing bracket
+		leaf := &SyntaxNode{Kind: SyntaxToken, Token: Token{Type: TAG_VALUE, Value: value}, Leading: " ", Raw: raw}
+		tag.Children = insertNode(tag.Children, len(tag.Children)-1, leaf)
+		return
+	}
+
+	tag := &SyntaxNode{Kind: SyntaxTag, Children: []*SyntaxNode{
+		{Kind: SyntaxToken, Token: Token{Type: TAG_START, Value: "["}, Raw: "["},
+		{Kind: SyntaxToken, Token: Token{Type: TAG_KEY, Value: key}, Raw: key},
+		{Kind: SyntaxToken, Token: Token{Type: TAG_VALUE, Value: value}, Leading: " ", Raw: raw},
+		{Kind: SyntaxToken, Token: Token{Type: TAG_END, Value: "]"}, Raw: "]"},
+	}}
+
+	index := -1
+	for i, child := range n.Children {
+		if child.Kind == SyntaxTag {
+			index = i
+		}
+	}
+	if index >= 0 {
+		tag.Children[0].Leading = "\n"
+		n.Children = insertNode(n.Children, index+1, tag)
+		return
+	}
+
+	// No tags yet, the new tag takes over the game's leading trivia
+	if first := n.firstLeaf(); first != nil {
+		tag.Children[0].Leading = first.Leading
+		first.Leading = "\n\n"
+	}
+	n.Children = insertNode(n.Children, 0, tag)
+}
+
+// RemoveTag deletes a tag from a game node, reporting whether it was present
+func (n *SyntaxNode) RemoveTag(key string) bool {
+	for i, child := range n.Children {
+		if child.Kind == SyntaxTag && child.tagKey() == key {
+			if i == 0 && len(n.Children) > 1 {
+				// Keep the trivia that started the game
+				if next := n.Children[1].firstLeaf(); next != nil {
+					next.Leading = child.firstLeaf().Leading
+				}
+			}
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	tagValueEscaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	tagValueUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+)
+
+func (n *SyntaxNode) findTag(key string) *SyntaxNode {
+	for _, child := range n.Children {
+		if child.Kind == SyntaxTag && child.tagKey() == key {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *SyntaxNode) tagKey() string {
+	if leaf := n.leaf(TAG_KEY); leaf != nil {
+		return leaf.Token.Value
+	}
+	return ""
+}
+
+// Helper to find the first direct leaf child of a given token type
+func (n *SyntaxNode) leaf(t TokenType) *SyntaxNode {
+	for _, child := range n.Children {
+		if child.Kind == SyntaxToken && child.Token.Type == t {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *SyntaxNode) firstLeaf() *SyntaxNode {
+	if n.Kind == SyntaxToken {
+		return n
+	}
+	for _, child := range n.Children {
+		if leaf := child.firstLeaf(); leaf != nil {
+			return leaf
+		}
+	}
+	return nil
+}
+
+func insertNode(nodes []*SyntaxNode, i int, node *SyntaxNode) []*SyntaxNode {
+	nodes = append(nodes, nil)
+	copy(nodes[i+1:], nodes[i:])
+	nodes[i] = node
+	return nodes
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Empty", input: ""},
+		{name: "Whitespace only", input: " \n\t\r\n"},
+		{name: "Simple game", input: "[Event \"Test\"]\n\n1. e4 e5 1-0\n"},
+		{name: "CRLF and tabs", input: "[Event  \"Test\" ]\r\n[Site\t\"X\"]\r\n\r\n1.e4   e5\t2.Nf3 *\r\n"},
+		{name: "Comments and commands", input: "1. e4 {  spaced   comment } e5 {[%clk 0:00:07] [%eval -6.05]  White is toast } 1-0"},
+		{name: "Command with parameters", input: "{[%command 1:45:12 , Nf6,\"very interesting, but wrong\"]}"},
+		{name: "Variations", input: "1. e4 ( 1. d4 (1. c4) ) 1... e5 (1...c5) 2. Nf3 $1 0-1"},
+		{name: "Unusual spellings", input: "1. Qa1xb2 Nbd7 2. N4xd5 exd8=Q+ 3. e7e8=N# O-O-O 4. O-O"},
+		{name: "Stray characters", input: "1. e4! e5?? 2. Nf3 1/2-1/2"},
+		{name: "Unterminated comment", input: "1. e4 { never closed"},
+		{name: "Invalid command", input: "1. e4 {[%clk 1:00} e5"},
+		{name: "Comment after the result", input: "[Event \"A\"]\n1. e4 1-0 {trailing}\n\n[Event \"B\"]\n1. d4 *\n"},
+		{name: "Comment alone", input: "{only a comment}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := ParseSyntax(tt.input)
+			if got := tree.String(); got != tt.input {
+				t.Errorf("Round trip mismatch\nExpected %q\ngot      %q", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestSyntaxRoundTripFixtures(t *testing.T) {
+	for _, name := range []string{"single_game.pgn", "multi_game.pgn"} {
+		data, err := os.ReadFile(filepath.Join("fixtures", name))
+		if err != nil {
+			t.Fatalf("Failed to read fixture %s: %v", name, err)
+		}
+
+		tree := ParseSyntax(string(data))
+		if got := tree.String(); got != string(data) {
+			t.Errorf("Round trip mismatch for %s", name)
+		}
+	}
+}
+
+func TestSyntaxStructure(t *testing.T) {
+	input := "[Event \"A\"]\n1. Qa1xb2 {c [%clk 1:00]} (1. Nbd7+) 1-0\n[Event \"B\"]\n1. O-O *"
+	tree := ParseSyntax(input)
+
+	games := tree.Games()
+	if len(games) != 2 {
+		t.Fatalf("Expected 2 games, got %d", len(games))
+	}
+
+	expected := []SyntaxKind{SyntaxTag, SyntaxToken, SyntaxToken, SyntaxMove, SyntaxComment, SyntaxVariation, SyntaxToken}
+	if len(games[0].Children) != len(expected) {
+		t.Fatalf("Expected %d children, got %d", len(expected), len(games[0].Children))
+	}
+	for i, kind := range expected {
+		if games[0].Children[i].Kind != kind {
+			t.Errorf("Child %d - Expected kind %v, got %v", i, kind, games[0].Children[i].Kind)
+		}
+	}
+
+	if move := games[0].Children[3]; len(move.Children) != 4 {
+		t.Errorf("Expected Qa1xb2 to be grouped into 4 tokens, got %d", len(move.Children))
+	}
+	if comment := games[0].Children[4]; comment.Children[2].Kind != SyntaxCommand {
+		t.Errorf("Expected command node inside comment, got %v", comment.Children[2].Kind)
+	}
+	if variation := games[0].Children[5]; variation.Children[3].Kind != SyntaxMove || len(variation.Children[3].Children) != 4 {
+		t.Errorf("Expected Nbd7+ to be grouped inside the variation")
+	}
+}
+
+func TestSyntaxTrailingComments(t *testing.T) {
+	tests := []struct {
+		input string
+		games int
+	}{
+		{"[Event \"A\"]\n1. e4 e5 1-0 {trailing comment}\n", 1},
+		{"1. e4 1-0 {a} {b [%clk 0:01:00]}\n[Event \"B\"]\n1. d4 *", 2},
+		{"{intro} [Event \"A\"]\n1. e4 *", 1},
+		{"{only a comment}", 0},
+		{"{unterminated", 0},
+	}
+	for _, tt := range tests {
+		tree := ParseSyntax(tt.input)
+		games := tree.Games()
+		if len(games) != tt.games {
+			t.Errorf("%q: expected %d games, got %d", tt.input, tt.games, len(games))
+			continue
+		}
+		for _, game := range games {
+			if !strings.Contains(game.String(), "1.") {
+				t.Errorf("%q: unexpected game %q", tt.input, game.String())
+			}
+		}
+	}
+}
+
+func TestSyntaxEditTags(t *testing.T) {
+	input := "[Event \"Example\"]\r\n[Site  \"Internet\"]\r\n\r\n1. e4   {odd   spacing} e5 1-0\r\n"
+
+	tests := []struct {
+		name     string
+		edit     func(game *SyntaxNode)
+		expected string
+	}{
+		{
+			name:     "Change tag",
+			edit:     func(game *SyntaxNode) { game.SetTag("Site", `Lichess "main"`) },
+			expected: "[Event \"Example\"]\r\n[Site  \"Lichess \\\"main\\\"\"]\r\n\r\n1. e4   {odd   spacing} e5 1-0\r\n",
+		},
+		{
+			name:     "Add tag",
+			edit:     func(game *SyntaxNode) { game.SetTag("Round", "3") },
+			expected: "[Event \"Example\"]\r\n[Site  \"Internet\"]\n[Round \"3\"]\r\n\r\n1. e4   {odd   spacing} e5 1-0\r\n",
+		},
+		{
+			name:     "Remove tag",
+			edit:     func(game *SyntaxNode) { game.RemoveTag("Event") },
+			expected: "[Site  \"Internet\"]\r\n\r\n1. e4   {odd   spacing} e5 1-0\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := ParseSyntax(input)
+			tt.edit(tree.Games()[0])
+			if got := tree.String(); got != tt.expected {
+				t.Errorf("Expected %q\ngot      %q", tt.expected, got)
+			}
+		})
+	}
+
+	tree := ParseSyntax(input)
+	if value, ok := tree.Games()[0].Tag("Site"); !ok || value != "Internet" {
+		t.Errorf("Expected Site tag %q, got %q (found %v)", "Internet", value, ok)
+	}
+
+	// Escaped values read back as they were set
+	tree.Games()[0].SetTag("Site", `Lichess "main" \ 2`)
+	if value, _ := ParseSyntax(tree.String()).Games()[0].Tag("Site"); value != `Lichess "main" \ 2` {
+		t.Errorf("Expected the escaped Site tag to round trip, got %q", value)
+	}
+}
+
+func FuzzSyntaxRoundTrip(f *testing.F) {
+	seeds := []string{
+		"[Event \"Test\"]\n1. e4 e5 2. Nf3 1-0",
+		"{[%clk 1:23:45]} {[%eval +1.2]}",
+		"1. e4 (1. d4 (1. c4)) 1... e5 *",
+		"Qa1xb2 Nbd7 e7e8=Q+ O-O-O",
+		"{[%,\"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		if len(input) > 1000 {
+			return
+		}
+		if got := ParseSyntax(input).String(); got != input {
+			t.Errorf("Round trip mismatch\nExpected %q\ngot      %q", input, got)
+		}
+	})
+}
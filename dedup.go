@@ -0,0 +1,132 @@
+package main
+
+import "strings"
+
+// DefaultDedupTags are the tags that must also match for games to be duplicates
+var DefaultDedupTags = []string{"White", "Black"}
+
+// GameRef locates a game in an input
+type GameRef struct {
+	File   string
+	Number int // Starting at 1
+	Line   int
+}
+
+// NewGameRef locates a scanned game
+func NewGameRef(file string, number int, game *Game) GameRef {
+	return GameRef{File: file, Number: number, Line: max(game.Line, 1)}
+}
+
+// DuplicateGroup is a set of copies of the same game
+type DuplicateGroup struct {
+	Key   string
+	Games []GameRef
+	Best  int // Index in Games of the most complete copy
+
+	best      *Game
+	bestScore int
+}
+
+// Text returns the source text of the most complete copy
+func (g *DuplicateGroup) Text() string {
+	return g.best.Raw
+}
+
+// Deduper groups games by their normalised main line and a set of tags
+type Deduper struct {
+	tags   []string
+	groups map[string]*DuplicateGroup
+	order  []*DuplicateGroup
+}
+
+// NewDeduper creates a deduper comparing the given tags along with the moves
+func NewDeduper(tags []string) *Deduper {
+	return &Deduper{tags: tags, groups: map[string]*DuplicateGroup{}}
+}
+
+// Add adds a scanned game to its group. A game ending in unreadable text, such
+// as an unterminated comment, is kept as is in a group of its own and its
+// error is returned.
+func (d *Deduper) Add(file string, number int, game *Game) error {
+	syntax := ParseSyntax(game.Raw)
+	if err := trailingError(syntax); err != nil {
+		d.order = append(d.order, &DuplicateGroup{Games: []GameRef{NewGameRef(file, number, game)}, best: game})
+		return err
+	}
+	node := syntax.Game()
+	if node == nil {
+		return nil
+	}
+	key := DedupKey(node, d.tags)
+	group, ok := d.groups[key]
+	if !ok {
+		group = &DuplicateGroup{Key: key, bestScore: -1}
+		d.groups[key] = group
+		d.order = append(d.order, group)
+	}
+	group.Games = append(group.Games, NewGameRef(file, number, game))
+	if score := completeness(node); score > group.bestScore {
+		group.best, group.bestScore = game, score
+		group.Best = len(group.Games) - 1
+	}
+	return nil
+}
+
+// Groups returns every group in the order their first game was added
+func (d *Deduper) Groups() []*DuplicateGroup {
+	return d.order
+}
+
+// DedupKey identifies a game by the values of some tags and its main line,
+// ignoring comments, NAGs, variations and move numbers. Moves are replayed
+// so that different spellings of a move (Ng1f3, Nf3) compare equal.
+func DedupKey(game *SyntaxNode, tags []string) string {
+	var sb strings.Builder
+	for _, key := range tags {
+		value, _ := game.Tag(key)
+		sb.WriteString(strings.TrimSpace(value))
+		sb.WriteByte(0)
+	}
+
+	if g, err := parseGameSyntax(game, nil); err == nil {
+		for _, node := range g.Mainline() {
+			sb.WriteString(node.Move.UCI())
+			sb.WriteByte(' ')
+		}
+		return sb.String()
+	}
+
+	// Games that cannot be replayed are compared as written
+	for _, child := range game.Children {
+		if child.Kind != SyntaxMove {
+			continue
+		}
+		for _, leaf := range child.Children {
+			sb.WriteString(leaf.Token.Value)
+		}
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// completeness scores a copy of a game by its known tags and annotations
+func completeness(game *SyntaxNode) int {
+	score := 0
+	game.Walk(func(n *SyntaxNode) bool {
+		switch n.Kind {
+		case SyntaxTag:
+			if value := n.leaf(TAG_VALUE); value != nil && strings.Trim(value.Token.Value, "?.- ") != "" {
+				score++
+			}
+			return false
+		case SyntaxComment, SyntaxVariation:
+			score++
+		case SyntaxToken:
+			if n.Token.Type == NAG || n.Token.Type == ANNOTATION {
+				score++
+			}
+		}
+		return true
+	})
+	return score
+}
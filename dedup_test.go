@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDedupKey(t *testing.T) {
+	key := func(pgn string, tags ...string) string {
+		return DedupKey(ParseSyntax(pgn).Games()[0], tags)
+	}
+
+	base := key("[White \"A\"]\n1. e4 e5 2. Nf3 *", "White")
+	same := []string{
+		"[White \"A\"]\n[Round \"3\"]\n1.e4 {best} e5 $1 (1... c5) 2.Ng1f3 1-0",
+		"[White \"A\"]\n1. e4 1... e5 2. Nf3! *",
+	}
+	for _, pgn := range same {
+		if got := key(pgn, "White"); got != base {
+			t.Errorf("Expected %q to have the same key", pgn)
+		}
+	}
+
+	different := []string{
+		"[White \"B\"]\n1. e4 e5 2. Nf3 *",
+		"[White \"A\"]\n1. e4 e5 2. Nc3 *",
+		"[White \"A\"]\n1. e4 e5 *",
+	}
+	for _, pgn := range different {
+		if got := key(pgn, "White"); got == base {
+			t.Errorf("Expected %q to have a different key", pgn)
+		}
+	}
+
+	// Games that cannot be replayed are compared as written
+	if key("[White \"A\"]\n1. e4 e5 2. Ke3 *") != key("[White \"B\"]\n1. e4 e5 2. Ke3 1-0") {
+		t.Errorf("Expected unreplayable games with the same moves to match")
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	input := "[Event \"A\"]\n[White \"X\"]\n[Black \"Y\"]\n\n1. e4 e5 *\n\n" +
+		"[Event \"B\"]\n[White \"X\"]\n[Black \"Z\"]\n\n1. d4 d5 *\n\n" +
+		"[Event \"A\"]\n[Site \"Here\"]\n[White \"X\"]\n[Black \"Y\"]\n\n1. e4 {main} e5 *\n"
+
+	d := NewDeduper(DefaultDedupTags)
+	scanner := NewScanner(strings.NewReader(input))
+	for n := 1; scanner.HasNext(); n++ {
+		game, err := scanner.ScanGame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Add("in.pgn", n, game)
+	}
+
+	groups := d.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+	g := groups[0]
+	if len(g.Games) != 2 || g.Best != 1 || g.Games[1].Number != 3 || g.Games[1].Line != 13 {
+		t.Errorf("Unexpected group %+v", g)
+	}
+	if !strings.Contains(g.Text(), "[Site \"Here\"]") {
+		t.Errorf("Expected the most complete copy to be kept, got %q", g.Text())
+	}
+}
+
+func TestDedupCommand(t *testing.T) {
+	input := "[Event \"A\"]\n[White \"X\"]\n\n1. e4 e5 *\n\n" +
+		"[Event \"A\"]\n[White \"X\"]\n\n1. e4 e5 $1 *\n"
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"dedup"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[Event \"A\"]\n[White \"X\"]\n\n1. e4 e5 $1 *\n\n"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"dedup", "-report"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "2 copies:\n  <stdin>:1: game 1\n  <stdin>:6: game 2 (kept)\n2 games, 1 unique, 1 duplicates removed\n"
+	if stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+
+	// A game ending in an unterminated comment is reported and kept whole
+	input = "[Event \"A\"]\n\n1. e4 e5 *\n\n[Event \"A\"]\n\n1. e4 e5 {unterminated\n"
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"dedup"}); err != errFailed {
+		t.Errorf("Expected the command to fail, got %v", err)
+	}
+	if want := "[Event \"A\"]\n\n1. e4 e5 *\n\n[Event \"A\"]\n\n1. e4 e5 {unterminated\n\n"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "<stdin>:7:11: error: unterminated comment") {
+		t.Errorf("Expected the comment to be reported, got %q", stderr.String())
+	}
+}
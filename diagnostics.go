@@ -0,0 +1,225 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewDiagnostic locates an error in a scanned game, such as one returned by
+// ParseGame, so that it can be rendered like a compiler message
+func NewDiagnostic(game *Game, err error) Diagnostic {
+	d := newDiagnostic(game, errorPos(err, 0), err.Error())
+	d.Severity = SeverityError
+	d.Hint = errorHint(err)
+	return d
+}
+
+// Helper to locate an error in a scanned game along with every other text
+// the lexer could not read, which parsing gives up at the first of. The
+// diagnostics are sorted by position.
+func gameDiagnostics(game *Game, err error) []Diagnostic {
+	var diags []Diagnostic
+	found := false
+	ParseSyntax(game.Raw).Walk(func(node *SyntaxNode) bool {
+		if node.Kind != SyntaxToken || node.Token.Error == nil {
+			return true
+		}
+		d := NewDiagnostic(game, node.Token.Error)
+		if node.Token.Type == ILLEGAL {
+			d.Message = fmt.Sprintf("%v %q", node.Token.Error, node.Raw)
+		}
+		found = found || errorPos(node.Token.Error, -1) == errorPos(err, -2)
+		diags = append(diags, d)
+		return true
+	})
+	if !found {
+		diags = append(diags, NewDiagnostic(game, err))
+	}
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int { return cmp.Compare(a.Offset, b.Offset) })
+	return diags
+}
+
+// Helper to build a diagnostic at a byte offset of a game, quoting its line
+func newDiagnostic(game *Game, pos int, message string) Diagnostic {
+	pos = min(max(pos, 0), len(game.Raw))
+	raw := game.Raw[:pos]
+	lineStart := strings.LastIndexByte(raw, '\n') + 1
+	lineEnd := strings.IndexByte(game.Raw[pos:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(game.Raw) - pos
+	}
+	return Diagnostic{
+		Message: message,
+		Offset:  game.Offset + int64(game.inputPos(pos)),
+		Line:    max(game.Line, 1) + strings.Count(raw, "\n"),
+		Column:  pos - lineStart + 1,
+		Length:  min(tokenLength(game.Raw, pos), lineEnd),
+		Source:  strings.TrimRight(game.Raw[lineStart:pos+lineEnd], "\r"),
+	}
+}
+
+// Helper to measure the token starting at pos, for underlining: a quoted
+// value up to its closing quote, a delimiter, or text up to a boundary
+func tokenLength(raw string, pos int) int {
+	switch {
+	case pos >= len(raw) || isWhitespace(raw[pos]):
+		return 0
+	case raw[pos] == '"':
+		if end := strings.IndexByte(raw[pos+1:], '"'); end >= 0 {
+			return end + 2
+		}
+		return 1
+	case isTokenBoundary(raw[pos]):
+		return 1
+	}
+	end := pos
+	for end < len(raw) && !isTokenBoundary(raw[end]) {
+		end++
+	}
+	return end - pos
+}
+
+// Helper to get the suggested fix of an error, if any
+func errorHint(err error) string {
+	if e, ok := err.(*PGNError); ok {
+		return e.Hint()
+	}
+	return ""
+}
+
+// Piece letters of other languages, mapped to the English ones PGN uses
+var foreignPieces = map[byte]byte{'S': 'N', 'L': 'B', 'T': 'R', 'D': 'Q'}
+
+// syntaxHint suggests a fix for text the lexer could not read, such as
+// castling written with lower case letters or a German piece letter
+func syntaxHint(text string) string {
+	move := strings.TrimRight(text, "+#!?")
+	suffix := text[len(move):]
+	switch strings.ToUpper(strings.ReplaceAll(move, "0", "O")) {
+	case "O-O", "OO":
+		return suggest("O-O" + suffix)
+	case "O-O-O", "OOO":
+		return suggest("O-O-O" + suffix)
+	}
+	switch move {
+	case "½-½", "1/2", "½", "0.5-0.5":
+		return suggest("1/2-1/2")
+	case "e.p.", "ep":
+		return "en passant captures are written like other captures, without e.p."
+	}
+	if len(move) < 3 || !isFile(move[len(move)-2]) || !isDigit(move[len(move)-1]) {
+		return ""
+	}
+	if strings.IndexByte("nrqk", move[0]) >= 0 {
+		return suggest(strings.ToUpper(move[:1])+text[1:]) + " Pieces are upper case"
+	}
+	if piece, ok := foreignPieces[move[0]]; ok {
+		return suggest(string(piece)+text[1:]) + " PGN uses English piece letters"
+	}
+	return ""
+}
+
+// Helper to suggest alternatives to a move, quoted so that a trailing
+// annotation such as "!" does not run into the question mark
+func suggest(moves ...string) string {
+	for i, move := range moves {
+		moves[i] = `"` + move + `"`
+	}
+	return "did you mean " + joinAlternatives(moves) + "?"
+}
+
+// moveHint suggests the legal moves a SAN move that failed to resolve may
+// have meant: those of the same kind of piece to the same square
+func moveHint(p *Position, san string) string {
+	move, _, _ := strings.Cut(strings.TrimRight(san, "+#!?"), "=")
+	if len(move) < 2 {
+		return ""
+	}
+	to, ok := parseSquare(move[len(move)-2:])
+	if !ok {
+		return ""
+	}
+	kind := byte('P')
+	if isPiece(move[0]) {
+		kind = move[0]
+	}
+	var candidates []string
+	for _, m := range p.LegalMoves() {
+		if m.To == to && pieceKind(p.PieceAt(m.From)) == kind {
+			if s := p.SAN(m); !slices.Contains(candidates, s) {
+				candidates = append(candidates, s)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	return suggest(candidates...)
+}
+
+// Helper to join words as "a", "a or b", or "a, b or c"
+func joinAlternatives(words []string) string {
+	if len(words) == 1 {
+		return words[0]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " or " + words[len(words)-1]
+}
+
+// ANSI escape sequences used to colour diagnostics
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[1;31m"
+	ansiAmber = "\x1b[1;33m"
+	ansiCyan  = "\x1b[1;36m"
+	ansiGreen = "\x1b[1;32m"
+)
+
+// Render formats the diagnostic like a compiler message: its location,
+// severity and message, the source line with the problem underlined, and the
+// hint if there is one. With color, ANSI escape sequences highlight it.
+func (d Diagnostic) Render(color bool) string {
+	paint := func(style, s string) string {
+		if !color {
+			return s
+		}
+		return style + s + ansiReset
+	}
+	style := map[Severity]string{SeverityError: ansiRed, SeverityWarning: ansiAmber, SeverityInfo: ansiCyan}[d.Severity]
+
+	var sb strings.Builder
+	sb.WriteString(paint(ansiBold, fmt.Sprintf("%s:%d:%d:", d.File, d.Line, d.Column)))
+	sb.WriteString(" " + paint(style, d.Severity.String()+":") + " " + d.Message)
+	if d.Rule != "" {
+		sb.WriteString(" [" + d.Rule + "]")
+	}
+	sb.WriteByte('\n')
+
+	number := fmt.Sprint(d.Line)
+	gutter := strings.Repeat(" ", len(number))
+	if d.Source != "" {
+		// Keep tabs so that the underline lines up, and count characters rather than bytes
+		prefix := d.Source[:min(d.Column-1, len(d.Source))]
+		var pad strings.Builder
+		for _, r := range prefix {
+			if r == '\t' {
+				pad.WriteByte('\t')
+			} else {
+				pad.WriteByte(' ')
+			}
+		}
+		underline := "^"
+		if end := min(len(prefix)+d.Length, len(d.Source)); end > len(prefix)+1 {
+			underline += strings.Repeat("~", utf8.RuneCountInString(d.Source[len(prefix):end])-1)
+		}
+		fmt.Fprintf(&sb, " %s | %s\n", paint(ansiBold, number), d.Source)
+		fmt.Fprintf(&sb, " %s | %s%s\n", gutter, pad.String(), paint(style, underline))
+	}
+	if d.Hint != "" {
+		fmt.Fprintf(&sb, " %s = %s %s\n", gutter, paint(ansiGreen, "hint:"), d.Hint)
+	}
+	return sb.String()
+}
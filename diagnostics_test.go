@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxHint(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"o-o", `did you mean "O-O"?`},
+		{"0-0-0+", `did you mean "O-O-O+"?`},
+		{"OO", `did you mean "O-O"?`},
+		{"½-½", `did you mean "1/2-1/2"?`},
+		{"e.p.", "en passant captures are written like other captures, without e.p."},
+		{"nf3", `did you mean "Nf3"? Pieces are upper case`},
+		{"Sxe5!", `did you mean "Nxe5!"? PGN uses English piece letters`},
+		{"Dd1", `did you mean "Qd1"? PGN uses English piece letters`},
+		{"ez5", ""},
+		{"%", ""},
+	}
+	for _, tt := range tests {
+		if got := syntaxHint(tt.text); got != tt.want {
+			t.Errorf("syntaxHint(%q) = %q, expected %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMoveHint(t *testing.T) {
+	tests := []struct {
+		fen  string
+		san  string
+		want string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nbf3", `did you mean "Nf3"?`},
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "Rd1", `did you mean "Rad1" or "Rhd1"?`},
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "Rd2", ""},
+		{"4k3/1P6/8/8/8/8/4K3/8 w - - 0 1", "b8=X", `did you mean "b8=Q+", "b8=R+", "b8=B" or "b8=N"?`},
+	}
+	for _, tt := range tests {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := moveHint(p, tt.san); got != tt.want {
+			t.Errorf("moveHint(%q) = %q, expected %q", tt.san, got, tt.want)
+		}
+	}
+}
+
+func TestNewDiagnostic(t *testing.T) {
+	game := &Game{Raw: "[Event \"E\"]\n\n1. e4 e5 2. Nbf3 *\n", Offset: 100, Line: 5}
+	_, err := ParseGame(game)
+	if !errors.Is(err, ErrIllegalMove(0)) {
+		t.Fatalf("Expected an illegal move, got %v", err)
+	}
+	d := NewDiagnostic(game, err)
+	d.File = "games.pgn"
+	if d.Line != 7 || d.Column != 13 || d.Offset != 125 || d.Length != 4 {
+		t.Errorf("Unexpected location %+v", d)
+	}
+	want := "games.pgn:7:13: error: illegal move\n" +
+		" 7 | 1. e4 e5 2. Nbf3 *\n" +
+		"   |             ^~~~\n" +
+		"   = hint: did you mean \"Nf3\"?\n"
+	if got := d.Render(false); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+	colored := d.Render(true)
+	if !strings.Contains(colored, ansiRed+"^~~~"+ansiReset) || !strings.Contains(colored, ansiGreen+"hint:"+ansiReset) {
+		t.Errorf("Expected color escapes, got %q", colored)
+	}
+}
+
+func TestDiagnosticOffsetLatin1(t *testing.T) {
+	// Offsets count the bytes of the input, not of the text converted to UTF-8
+	input := "[Event \"E\"]\n[White \"M\xfcller \xe9\xe8\"]\n\n1. e5 *\n"
+	game, err := NewScanner(strings.NewReader(input)).ScanGame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseGame(game)
+	if d := NewDiagnostic(game, err); d.Offset != int64(strings.Index(input, "e5")) || d.Line != 4 || d.Column != 4 {
+		t.Errorf("Unexpected location %+v", d)
+	}
+}
+
+func TestGameDiagnostics(t *testing.T) {
+	// Parsing stops at the first unreadable text, the others are reported too
+	game := &Game{Raw: "[Event \"E\"]\n\n1. e4 e5 2. Bz5 a6\n3. Qz4 *", Line: 1}
+	_, err := ParseGame(game)
+	var got []string
+	for _, d := range gameDiagnostics(game, err) {
+		got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+	}
+	want := []string{`3:14 invalid square "z5"`, `4:5 invalid square "z4"`}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestRenderTabsAndUnicode(t *testing.T) {
+	game := &Game{Raw: "1.\te4 {é} ½-½", Line: 1}
+	d := newDiagnostic(game, strings.Index(game.Raw, "½"), "unexpected character")
+	d.Severity = SeverityError
+	want := ":1:12: error: unexpected character\n" +
+		" 1 | 1.\te4 {é} ½-½\n" +
+		"   |   \t       ^~~\n"
+	if got := d.Render(false); got != want {
+		t.Errorf("Expected:\n%q\ngot:\n%q", want, got)
+	}
+}
+
+func TestLintPretty(t *testing.T) {
+	input := lintRoster + "1. e4 e5 o-o 2. Sf3 Nc6 1-0\n"
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"lint", "-pretty", "-color", "never"}); err != errFailed {
+		t.Errorf("Expected the syntax errors to fail the command, got %v", err)
+	}
+	got := stdout.String()
+	for _, want := range []string{
+		"<stdin>:9:10: error: invalid square \"o-o\" [syntax-error]\n 9 | 1. e4 e5 o-o 2. Sf3 Nc6 1-0\n   |          ^~~\n   = hint: did you mean \"O-O\"?\n",
+		"<stdin>:9:17: error: invalid piece \"Sf3\" [syntax-error]\n",
+		"   = hint: did you mean \"Nf3\"? PGN uses English piece letters\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("Expected no color, got %q", got)
+	}
+}
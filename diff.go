@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Lines of context around each change in a unified diff
+const diffContext = 3
+
+// diffLine is a line of an edit script: ' ' kept, '-' removed or '+' added
+type diffLine struct {
+	op   byte
+	text string // Including its newline, if any
+}
+
+// diffLines computes the edit script turning a into b from their longest
+// common subsequence of lines, in time and space proportional to the product
+// of their line counts. Callers diff one game at a time.
+func diffLines(a, b string) []diffLine {
+	x, y := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the LCS of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var script []diffLine
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			script = append(script, diffLine{' ', x[i]})
+			i++
+			j++
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			script = append(script, diffLine{'-', x[i]})
+			i++
+		default:
+			script = append(script, diffLine{'+', y[j]})
+			j++
+		}
+	}
+	return script
+}
+
+// Helper to split text into lines, keeping their newlines
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeUnifiedDiff writes an edit script as a unified diff, writing nothing
+// if there are no changes
+func writeUnifiedDiff(w io.Writer, oldName, newName string, script []diffLine) error {
+	var sb strings.Builder
+	oldLine, newLine := 1, 1 // Line numbers at the start of script[i]
+	for i := 0; i < len(script); {
+		if script[i].op == ' ' {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		start := max(i-diffContext, 0)
+		end := i
+		for k := i; k < len(script); k++ {
+			if script[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(script))
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, line := range script[start:end] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+			body.WriteByte(line.op)
+			body.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		sb.WriteString(body.String())
+
+		for _, line := range script[i:end] {
+			if line.op != '+' {
+				oldLine++
+			}
+			if line.op != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	if sb.Len() == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "--- %s\n+++ %s\n%s", oldName, newName, sb.String())
+	return err
+}
+
+// Helper to format the line range of a hunk, where an empty range starts
+// before its line
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ecoTable lists openings as tab-separated ECO code, name and moves, the
+// name being "Opening: Variation" when it has a variation
+//
+//go:embed data/eco.tsv
+var ecoTable string
+
+// Opening is an entry of the embedded ECO table
+type Opening struct {
+	ECO   string
+	Name  string // Such as "Sicilian Defense: Najdorf Variation"
+	Moves string // Such as "1. e4 c5 2. Nf3 d6"
+	Ply   int    // Number of moves of the line
+}
+
+// Family returns the opening name without its variation
+func (o Opening) Family() string {
+	family, _, _ := strings.Cut(o.Name, ":")
+	return strings.TrimSpace(family)
+}
+
+// Variation returns the variation part of the name, empty if there is none
+func (o Opening) Variation() string {
+	_, variation, _ := strings.Cut(o.Name, ":")
+	return strings.TrimSpace(variation)
+}
+
+var (
+	ecoOnce      sync.Once
+	ecoOpenings  []Opening
+	ecoPositions map[uint64]int      // Index in ecoOpenings of the opening reaching a position
+	ecoCodes     map[string][]uint64 // Positions of the lines of each ECO code
+)
+
+// Helper to parse the embedded table once. Positions reached by several
+// lines keep the first one listed.
+func loadOpenings() {
+	ecoPositions = map[uint64]int{}
+	ecoCodes = map[string][]uint64{}
+	for i, line := range strings.Split(strings.TrimSpace(ecoTable), "\n") {
+		fields := strings.Split(line, "\t")
+		if i == 0 {
+			continue // Header
+		}
+		if len(fields) != 3 {
+			panic(fmt.Sprintf("eco.tsv: line %d: expected 3 fields, got %d", i+1, len(fields)))
+		}
+		p, err := PlayMoves(NewPosition(), fields[2])
+		if err != nil {
+			panic(fmt.Sprintf("eco.tsv: line %d: %v", i+1, err))
+		}
+		o := Opening{ECO: fields[0], Name: fields[1], Moves: fields[2]}
+		for _, word := range strings.Fields(o.Moves) {
+			if !strings.HasSuffix(word, ".") {
+				o.Ply++
+			}
+		}
+		if _, ok := ecoPositions[p.Hash()]; !ok {
+			ecoPositions[p.Hash()] = len(ecoOpenings)
+		}
+		ecoCodes[o.ECO] = append(ecoCodes[o.ECO], p.Hash())
+		ecoOpenings = append(ecoOpenings, o)
+	}
+}
+
+// Openings returns the entries of the embedded ECO table
+func Openings() []Opening {
+	ecoOnce.Do(loadOpenings)
+	return append([]Opening(nil), ecoOpenings...)
+}
+
+// LookupOpening returns the opening whose line reaches a position, by any
+// move order
+func LookupOpening(p *Position) (Opening, bool) {
+	ecoOnce.Do(loadOpenings)
+	i, ok := ecoPositions[p.Hash()]
+	if !ok {
+		return Opening{}, false
+	}
+	return ecoOpenings[i], true
+}
+
+// ClassifyOpening returns the deepest opening of the ECO table, by the
+// length of its line, that the main line reaches. Positions are matched by
+// hash, so transpositions into a known line are recognised.
+func ClassifyOpening(g *ParsedGame) (Opening, bool) {
+	var opening Opening
+	found := false
+	for _, node := range g.Mainline() {
+		if o, ok := LookupOpening(node.Position); ok && (!found || o.Ply >= opening.Ply) {
+			opening, found = o, true
+		}
+	}
+	return opening, found
+}
+
+// ClassifyGame classifies a game and fills its missing ECO, Opening and
+// Variation tags, replacing them when the moves contradict the ECO code. It
+// reports whether the opening was found. Unknown openings leave the tags as
+// they are.
+func ClassifyGame(g *ParsedGame) (Opening, bool) {
+	o, ok := ClassifyOpening(g)
+	if ok {
+		setOpeningTags(g, g, o, correctTags)
+	}
+	return o, ok
+}
+
+// tagPolicy tells setOpeningTags what to do with existing tags
+type tagPolicy int
+
+const (
+	fillTags      tagPolicy = iota // Only fill missing tags
+	correctTags                    // Also replace the tags when the moves contradict the ECO code
+	overwriteTags                  // Always replace the tags
+)
+
+// Helper to tell whether the main line of a game contradicts an ECO code,
+// never reaching a line of that code. Codes the table lacks, such as
+// malformed ones, are trusted.
+func ecoContradicted(g *ParsedGame, code string) bool {
+	ecoOnce.Do(loadOpenings)
+	positions, ok := ecoCodes[code]
+	if !ok {
+		return false
+	}
+	for _, node := range g.Mainline() {
+		if slices.Contains(positions, node.Position.Hash()) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagEditor is implemented by both ParsedGame and game syntax nodes
+type tagEditor interface {
+	Tag(key string) (string, bool)
+	SetTag(key, value string)
+	RemoveTag(key string) bool
+}
+
+// Helper to set the ECO, Opening and Variation tags of tags, the game g
+// classified as o, keeping existing values as policy says. Returns whether a
+// tag changed.
+func setOpeningTags(tags tagEditor, g *ParsedGame, o Opening, policy tagPolicy) bool {
+	overwrite := policy == overwriteTags
+	if code, ok := tags.Tag("ECO"); ok && policy == correctTags && strings.Trim(code, "?") != "" {
+		overwrite = ecoContradicted(g, code)
+	}
+	changed := false
+	for _, tag := range []Tag{{"ECO", o.ECO}, {"Opening", o.Family()}, {"Variation", o.Variation()}} {
+		value, ok := tags.Tag(tag.Key)
+		switch {
+		case ok && !overwrite && strings.Trim(value, "?") != "":
+		case tag.Value == "":
+			// A variation left over from a deeper line
+			if ok && overwrite && tags.RemoveTag(tag.Key) {
+				changed = true
+			}
+		case !ok || value != tag.Value:
+			tags.SetTag(tag.Key, tag.Value)
+			changed = true
+		}
+	}
+	return changed
+}
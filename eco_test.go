@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOpeningTable(t *testing.T) {
+	openings := Openings()
+	if len(openings) < 100 {
+		t.Fatalf("Expected a table of openings, got %d entries", len(openings))
+	}
+	seen := map[uint64]string{}
+	for _, o := range openings {
+		if _, err := ParseECO(o.ECO); err != nil {
+			t.Errorf("%s: %v", o.Name, err)
+		}
+		p, err := PlayMoves(NewPosition(), o.Moves)
+		if err != nil {
+			t.Errorf("%s: %v", o.Name, err)
+			continue
+		}
+		if other, ok := seen[p.Hash()]; ok {
+			t.Errorf("%s reaches the same position as %s", o.Name, other)
+		}
+		seen[p.Hash()] = o.Name
+	}
+}
+
+func TestClassifyOpening(t *testing.T) {
+	tests := []struct {
+		moves     string
+		eco, name string
+	}{
+		{"1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7 6. Re1", "C84", "Ruy Lopez: Closed"},
+		// Out of the table after 5... a6, the deepest known position wins
+		{"1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. h3", "B90", "Sicilian Defense: Najdorf Variation"},
+		// A transposition into the Queen's Gambit Declined
+		{"1. c4 e6 2. d4 d5", "D30", "Queen's Gambit Declined"},
+		{"1. c4 e5 2. Nc3", "A21", "English Opening: King's English Variation, Reversed Sicilian"},
+		// Deep lines of the complete table
+		{"1. d4 Nf6 2. c4 e6 3. Nc3 Bb4 4. e3 O-O 5. Nf3 d5 6. Bd3 c5 7. O-O Nc6 8. a3 Bxc3 9. bxc3 dxc4 10. Bxc4 Qc7",
+			"E59", "Nimzo-Indian Defense: Normal Variation, Gligoric System, Bernstein Defense, Main Line"},
+		{"1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. Bg5 e6 7. f4 Qb6", "B97", "Sicilian Defense: Najdorf Variation, Poisoned Pawn Variation"},
+		{"1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3 O-O 9. h3 Na5 10. Bc2 c5 11. d4 Qc7 12. Nbd2 cxd4 13. cxd4",
+			"C99", "Ruy Lopez: Closed, Chigorin Defense"},
+		// The Grünfeld by another move order
+		{"1. d4 Nf6 2. c4 g6 3. Nf3 d5 4. Nc3", "D90", "Grünfeld Defense: Three Knights Variation"},
+		// The deepest line wins over a shallower one reached later
+		{"1. e4 e5 2. Nf3 Nc6 3. Bb5 Nb8 4. Bf1 Nc6", "C60", "Ruy Lopez"},
+	}
+	for _, tt := range tests {
+		g, err := ParseGame(&Game{Raw: tt.moves + " *"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, ok := ClassifyOpening(g)
+		if !ok || o.ECO != tt.eco || o.Name != tt.name {
+			t.Errorf("%s: expected %s %s, got %s %s (%v)", tt.moves, tt.eco, tt.name, o.ECO, o.Name, ok)
+		}
+	}
+
+	g, _ := ParseGame(&Game{Raw: "[FEN \"8/8/8/8/8/8/k7/7K w - - 0 1\"]\n[SetUp \"1\"]\n1. Kg1 *"})
+	if o, ok := ClassifyOpening(g); ok {
+		t.Errorf("Expected no opening for an endgame, got %+v", o)
+	}
+}
+
+func TestClassifyGame(t *testing.T) {
+	g, err := ParseGame(&Game{Raw: "[ECO \"A00\"]\n[Variation \"Old\"]\n1. e4 e5 2. Nf3 Nc6 3. Bb5 *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ClassifyGame(g); !ok {
+		t.Fatal("Expected the Ruy Lopez")
+	}
+	eco, _ := g.Tag("ECO")
+	opening, _ := g.Tag("Opening")
+	if _, ok := g.Tag("Variation"); ok || eco != "C60" || opening != "Ruy Lopez" {
+		t.Errorf("Unexpected tags %+v", g.Tags)
+	}
+	// A code whose line the moves pass through is consistent with them
+	g, err = ParseGame(&Game{Raw: "[ECO \"C90\"]\n[Opening \"Ruy Lopez\"]\n[Variation \"Closed\"]\n" +
+		"1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3 O-O 9. h3 *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := ClassifyGame(g); o.ECO != "C92" {
+		t.Errorf("Expected the C92 line, got %s", o.ECO)
+	}
+	if eco, _ := g.Tag("ECO"); eco != "C90" {
+		t.Errorf("Expected C90 to be kept, got %s", eco)
+	}
+}
+
+func TestECOCommand(t *testing.T) {
+	input := "[Event \"A\"]\n[ECO \"?\"]\n[Opening \"Mine\"]\n\n1. e4 c5 2. Nf3 e6 {French} *\n\n" +
+		"[Event \"B\"]\n[FEN \"8/8/8/8/8/8/k7/7K w - - 0 1\"]\n[SetUp \"1\"]\n\n1. Kg1 *\n"
+
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"eco", "-keep"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "[Event \"A\"]\n[ECO \"B40\"]\n[Opening \"Mine\"]\n[Variation \"French Variation\"]\n\n1. e4 c5 2. Nf3 e6 {French} *\n\n" +
+		"[Event \"B\"]\n[FEN \"8/8/8/8/8/8/k7/7K w - - 0 1\"]\n[SetUp \"1\"]\n\n1. Kg1 *\n\n"
+	if stdout.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"eco", "-l"}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "<stdin>: game 1\n" {
+		t.Errorf("Unexpected listing %q", stdout.String())
+	}
+	// Only -overwrite replaces tags the moves agree with
+	input = "[Event \"A\"]\n[ECO \"C60\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bb5 Nf6 *\n"
+	for flag, want := range map[string]string{"-keep": "C60", "": "C60", "-overwrite": "C65"} {
+		stdout.Reset()
+		e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+		args := []string{"eco"}
+		if flag != "" {
+			args = append(args, flag)
+		}
+		if err := run(e, args); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stdout.String(), "[ECO \""+want+"\"]") {
+			t.Errorf("%s: expected ECO %s, got\n%s", flag, want, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+// Editing of a game tree, such as promoting a variation to the main line.
+// A node's first child is its main continuation and the others are the
+// variations replacing it, in order, as WritePGN writes them.
+
+// Variation returns the child of a node reached by a SAN move, or nil
+func (n *MoveNode) Variation(san string) *MoveNode {
+	for _, child := range n.Children {
+		if child.SAN == san {
+			return child
+		}
+	}
+	return nil
+}
+
+// Index returns the position of a node among its siblings, 0 for the main
+// continuation, or -1 for the root
+func (n *MoveNode) Index() int {
+	if n.Parent == nil {
+		return -1
+	}
+	for i, child := range n.Parent.Children {
+		if child == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsMainline reports whether the node is on the main line of the game
+func (n *MoveNode) IsMainline() bool {
+	for node := n; node.Parent != nil; node = node.Parent {
+		if node.Index() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MoveTo reorders a node among its siblings so that it ends at index i,
+// shifting the others. Index 0 makes it the main continuation. It reports
+// whether the node could be moved.
+func (n *MoveNode) MoveTo(i int) bool {
+	from := n.Index()
+	if from < 0 || i < 0 || i >= len(n.Parent.Children) {
+		return false
+	}
+	siblings := n.Parent.Children
+	if i < from {
+		copy(siblings[i+1:from+1], siblings[i:from])
+	} else {
+		copy(siblings[from:i], siblings[from+1:i+1])
+	}
+	siblings[i] = n
+	return true
+}
+
+// Promote moves a node one place up among its siblings, so that a first
+// variation replaces the main continuation. It reports whether it moved.
+func (n *MoveNode) Promote() bool {
+	return n.MoveTo(n.Index() - 1)
+}
+
+// Demote moves a node one place down among its siblings, so that the main
+// continuation becomes the first variation. It reports whether it moved.
+func (n *MoveNode) Demote() bool {
+	if n.Index() < 0 {
+		return false
+	}
+	return n.MoveTo(n.Index() + 1)
+}
+
+// PromoteToMain makes the line leading to a node the main line of the game,
+// promoting the node and every variation it is in to index 0
+func (n *MoveNode) PromoteToMain() {
+	for node := n; node.Parent != nil; node = node.Parent {
+		node.MoveTo(0)
+	}
+}
+
+// Delete removes a node and all the moves after it from the tree. Deleting
+// the main continuation promotes the first variation in its place. It
+// reports whether the node was removed, which the root cannot be.
+func (n *MoveNode) Delete() bool {
+	i := n.Index()
+	if i < 0 {
+		return false
+	}
+	siblings := n.Parent.Children
+	n.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+	n.Parent = nil
+	return true
+}
+
+// Truncate removes every move after the node, variations included
+func (n *MoveNode) Truncate() {
+	for _, child := range n.Children {
+		child.Parent = nil
+	}
+	n.Children = nil
+}
+
+// StripComments removes the comments and commands of a node and of every
+// move after it. On the root it strips the whole game.
+func (n *MoveNode) StripComments() {
+	n.walk(func(node *MoveNode) {
+		node.StartingComments, node.Comments, node.Commands = nil, nil, nil
+	})
+}
+
+// StripNAGs removes the NAGs of a node and of every move after it
+func (n *MoveNode) StripNAGs() {
+	n.walk(func(node *MoveNode) {
+		node.NAGs = nil
+	})
+}
+
+// Helper to visit a node and its descendants, depth first
+func (n *MoveNode) walk(fn func(*MoveNode)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.walk(fn)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Helper to parse a game for editing
+func parseEditGame(t *testing.T, movetext string) *ParsedGame {
+	t.Helper()
+	g, err := parseGameSyntax(ParseSyntax(movetext).Games()[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// Helper to follow SAN moves from the root, through variations
+func followMoves(t *testing.T, g *ParsedGame, moves ...string) *MoveNode {
+	t.Helper()
+	node := g.Root
+	for _, san := range moves {
+		if node = node.Variation(san); node == nil {
+			t.Fatalf("No move %s in %v", san, moves)
+		}
+	}
+	return node
+}
+
+func TestEditTree(t *testing.T) {
+	const game = "1. e4 {best} 1... e5 (1... c5 2. Nf3 (2. c3) 2... d6) (1... e6 $2) 2. Nf3 $1 Nc6 *"
+	tests := []struct {
+		name  string
+		moves []string
+		edit  func(n *MoveNode) bool
+		want  string
+	}{
+		{"promote", []string{"e4", "c5"}, (*MoveNode).Promote, "1. e4 {best} 1... c5 (1... e5 2. Nf3 $1 Nc6) (1... e6 $2) 2. Nf3 (2. c3) 2... d6 *"},
+		{"promote main", []string{"e4", "e5"}, (*MoveNode).Promote, ""},
+		{"demote", []string{"e4", "e5"}, (*MoveNode).Demote, "1. e4 {best} 1... c5 (1... e5 2. Nf3 $1 Nc6) (1... e6 $2) 2. Nf3 (2. c3) 2... d6 *"},
+		{"demote last", []string{"e4", "e6"}, (*MoveNode).Demote, ""},
+		{"promote to main", []string{"e4", "c5", "c3"}, func(n *MoveNode) bool {
+			n.PromoteToMain()
+			return true
+		}, "1. e4 {best} 1... c5 (1... e5 2. Nf3 $1 Nc6) (1... e6 $2) 2. c3 (2. Nf3 d6) *"},
+		{"reorder", []string{"e4", "e6"}, func(n *MoveNode) bool { return n.MoveTo(1) }, "1. e4 {best} 1... e5 (1... e6 $2) (1... c5 2. Nf3 (2. c3) 2... d6) 2. Nf3 $1 Nc6 *"},
+		{"reorder out of range", []string{"e4", "e6"}, func(n *MoveNode) bool { return n.MoveTo(3) }, ""},
+		{"delete variation", []string{"e4", "c5"}, (*MoveNode).Delete, "1. e4 {best} 1... e5 (1... e6 $2) 2. Nf3 $1 Nc6 *"},
+		{"delete main", []string{"e4", "e5"}, (*MoveNode).Delete, "1. e4 {best} 1... c5 (1... e6 $2) 2. Nf3 (2. c3) 2... d6 *"},
+		{"delete root", nil, (*MoveNode).Delete, ""},
+		{"truncate", []string{"e4"}, func(n *MoveNode) bool {
+			n.Truncate()
+			return true
+		}, "1. e4 {best} *"},
+		{"strip comments", nil, func(n *MoveNode) bool {
+			n.StripComments()
+			return true
+		}, "1. e4 e5 (1... c5 2. Nf3 (2. c3) 2... d6) (1... e6 $2) 2. Nf3 $1 Nc6 *"},
+		{"strip NAGs of a subtree", []string{"e4", "e6"}, func(n *MoveNode) bool {
+			n.StripNAGs()
+			return true
+		}, "1. e4 {best} 1... e5 (1... c5 2. Nf3 (2. c3) 2... d6) (1... e6) 2. Nf3 $1 Nc6 *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := parseEditGame(t, game)
+			if ok := tt.edit(followMoves(t, g, tt.moves...)); ok != (tt.want != "") {
+				t.Fatalf("Expected the edit to report %v", !ok)
+			}
+			want := tt.want
+			if want == "" {
+				want = game
+			}
+			if got := strings.Join(strings.Fields(g.String()), " "); got != want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+			}
+			// The edited game must parse back to the same PGN
+			if again := parseEditGame(t, g.String()); again.String() != g.String() {
+				t.Errorf("Round trip changed the game:\n%s", again.String())
+			}
+		})
+	}
+}
+
+func TestEditNavigation(t *testing.T) {
+	g := parseEditGame(t, "1. e4 e5 (1... c5 2. Nf3) 2. Nf3 *")
+	c5 := followMoves(t, g, "e4", "c5")
+	if c5.Index() != 1 || c5.IsMainline() || g.Root.Index() != -1 || !g.Root.IsMainline() {
+		t.Errorf("Unexpected index %d or main line of 1... c5", c5.Index())
+	}
+	nf3 := c5.Next()
+	nf3.PromoteToMain()
+	if !nf3.IsMainline() || len(g.Mainline()) != 3 || g.Mainline()[1] != c5 {
+		t.Errorf("Expected 1... c5 2. Nf3 as the main line, got %s", g.String())
+	}
+	if g.Root.Variation("d4") != nil {
+		t.Errorf("Expected no 1. d4")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of PGN input
+type Encoding int
+
+const (
+	EncodingAuto        Encoding = iota // UTF-8, or Windows-1252 for games that are not valid UTF-8
+	EncodingUTF8                        // Invalid bytes become U+FFFD
+	EncodingLatin1                      // ISO 8859-1, the encoding of the PGN standard
+	EncodingWindows1252                 // Latin-1 with printable characters in 0x80-0x9F
+)
+
+var encodingNames = [...]string{"auto", "utf-8", "latin-1", "windows-1252"}
+
+func (enc Encoding) String() string {
+	return encodingNames[enc]
+}
+
+// ParseEncoding parses an encoding name such as utf-8, latin-1 or cp1252
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "auto", "":
+		return EncodingAuto, nil
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "latin-1", "latin1", "iso-8859-1", "iso8859-1":
+		return EncodingLatin1, nil
+	case "windows-1252", "cp1252":
+		return EncodingWindows1252, nil
+	}
+	return 0, fmt.Errorf("unknown encoding %q, expected auto, utf-8, latin-1 or windows-1252", name)
+}
+
+// Characters of Windows-1252 from 0x80 to 0x9F. The five unassigned bytes
+// keep their Latin-1 meaning.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// DecodeText converts text in an encoding to valid UTF-8. With EncodingAuto,
+// text that is already valid UTF-8 is kept and anything else is read as
+// Windows-1252, which older databases and ChessBase exports use.
+func DecodeText(b []byte, enc Encoding) string {
+	switch enc {
+	case EncodingAuto:
+		if utf8.Valid(b) {
+			return string(b)
+		}
+		return decodeSingleByte(b, true)
+	case EncodingLatin1:
+		return decodeSingleByte(b, false)
+	case EncodingWindows1252:
+		return decodeSingleByte(b, true)
+	}
+	return strings.ToValidUTF8(string(b), string(utf8.RuneError))
+}
+
+func decodeSingleByte(b []byte, cp1252 bool) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+	for _, c := range b {
+		switch {
+		case c < utf8.RuneSelf:
+			sb.WriteByte(c)
+		case cp1252 && c < 0xa0:
+			sb.WriteRune(windows1252[c-0x80])
+		default:
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
+
+// Byte order marks
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// bomReader transcodes UTF-16 input starting with a byte order mark to
+// UTF-8, passing anything else through. It only looks at the input on the
+// first read.
+type bomReader struct {
+	r   io.Reader
+	src io.Reader
+}
+
+func (b *bomReader) Read(p []byte) (int, error) {
+	if b.src == nil {
+		br := bufio.NewReader(b.r)
+		b.src = br
+		header, _ := br.Peek(2)
+		switch {
+		case string(header) == string(utf16LEBOM):
+			br.Discard(2)
+			b.src = &utf16Reader{r: br, order: binary.LittleEndian}
+		case string(header) == string(utf16BEBOM):
+			br.Discard(2)
+			b.src = &utf16Reader{r: br, order: binary.BigEndian}
+		}
+	}
+	return b.src.Read(p)
+}
+
+// utf16Reader converts UTF-16 to UTF-8
+type utf16Reader struct {
+	r          *bufio.Reader
+	order      binary.ByteOrder
+	buf        []byte // Converted text not read yet
+	pending    rune   // Code unit read after an unpaired high surrogate
+	hasPending bool
+	err        error
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.buf) < len(p) && u.err == nil {
+		var r rune
+		r, u.err = u.readRune()
+		if u.err == nil {
+			u.buf = utf8.AppendRune(u.buf, r)
+		}
+	}
+	n := copy(p, u.buf)
+	u.buf = u.buf[n:]
+	if n == 0 {
+		return 0, u.err
+	}
+	return n, nil
+}
+
+// Helper to read a code unit, a truncated one being invalid
+func (u *utf16Reader) unit() (rune, error) {
+	if u.hasPending {
+		u.hasPending = false
+		return u.pending, nil
+	}
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	return rune(u.order.Uint16(b[:])), nil
+}
+
+func (u *utf16Reader) readRune() (rune, error) {
+	r, err := u.unit()
+	if err != nil || !utf16.IsSurrogate(r) {
+		return r, err
+	}
+	if r >= 0xdc00 {
+		return utf8.RuneError, nil // Low surrogate without a high one
+	}
+	low, err := u.unit()
+	if err != nil {
+		return utf8.RuneError, nil // The error comes back on the next read
+	}
+	if decoded := utf16.DecodeRune(r, low); decoded != utf8.RuneError {
+		return decoded, nil
+	}
+	// Not a pair, the second unit starts the next character
+	u.pending, u.hasPending = low, true
+	return utf8.RuneError, nil
+}
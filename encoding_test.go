@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		enc  Encoding
+		want string
+	}{
+		{"utf-8 kept", "Müller", EncodingAuto, "Müller"},
+		{"latin-1 detected", "M\xfcller", EncodingAuto, "Müller"},
+		{"windows-1252 detected", "\x93Ljubojevi\x9a\x94", EncodingAuto, "“Ljubojeviš”"},
+		{"latin-1", "\x80", EncodingLatin1, "\u0080"},
+		{"windows-1252", "\x80", EncodingWindows1252, "€"},
+		{"unassigned windows-1252", "\x81", EncodingWindows1252, "\u0081"},
+		{"invalid utf-8", "M\xfcller", EncodingUTF8, "M�ller"},
+		{"utf-8 read as latin-1", "Müller", EncodingLatin1, "MÃ¼ller"},
+	}
+	for _, tt := range tests {
+		if got := DecodeText([]byte(tt.in), tt.enc); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for name, want := range map[string]Encoding{"": EncodingAuto, "UTF-8": EncodingUTF8, "iso-8859-1": EncodingLatin1, "CP1252": EncodingWindows1252} {
+		if got, err := ParseEncoding(name); err != nil || got != want {
+			t.Errorf("ParseEncoding(%q) = %v, %v, expected %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseEncoding("ebcdic"); err == nil {
+		t.Errorf("Expected an error for an unknown encoding")
+	}
+}
+
+func TestScannerEncodings(t *testing.T) {
+	utf16Data := func(order binary.ByteOrder, bom []byte, s string) string {
+		buf := bytes.NewBuffer(bom)
+		for _, unit := range utf16.Encode([]rune(s)) {
+			binary.Write(buf, order, unit)
+		}
+		return buf.String()
+	}
+	game := "[White \"Müller ♔\"]\n\n1. e4 *"
+	tests := []struct {
+		name   string
+		input  string
+		offset int64
+		size   int
+	}{
+		{"utf-8", game, 0, len(game)},
+		{"utf-8 with BOM", "\xef\xbb\xbf" + game, 3, len(game)},
+		{"latin-1", "[White \"M\xfcller ♔\"]\n\n1. e4 *", 0, len(game) - 1},
+		{"utf-16le", utf16Data(binary.LittleEndian, utf16LEBOM, game), 0, len(game)},
+		{"utf-16be", utf16Data(binary.BigEndian, utf16BEBOM, game), 0, len(game)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewScanner(strings.NewReader(tt.input)).ScanGame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.name == "latin-1" {
+				// The ♔ was valid UTF-8, but the game as a whole is not
+				if g.Raw != "[White \"Müller â™”\"]\n\n1. e4 *" {
+					t.Errorf("Unexpected text %q", g.Raw)
+				}
+			} else if g.Raw != game {
+				t.Errorf("Expected %q, got %q", game, g.Raw)
+			}
+			if g.Offset != tt.offset || g.Size != tt.size {
+				t.Errorf("Expected offset %d and size %d, got %d and %d", tt.offset, tt.size, g.Offset, g.Size)
+			}
+		})
+	}
+}
+
+func TestEncodingOption(t *testing.T) {
+	input := "[Event \"E\"]\n[White \"\x80\"]\n\n1. e4 *\n"
+	for _, tt := range []struct{ encoding, want string }{{"auto", "€"}, {"latin-1", "\u0080"}} {
+		var stdout bytes.Buffer
+		e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+		if err := run(e, []string{"-encoding", tt.encoding, "filter"}); err != nil {
+			t.Fatal(err)
+		}
+		if want := "[Event \"E\"]\n[White \"" + tt.want + "\"]\n\n1. e4 *\n\n"; stdout.String() != want {
+			t.Errorf("-encoding %s: expected %q, got %q", tt.encoding, want, stdout.String())
+		}
+	}
+
+	e := &env{stdin: strings.NewReader(input), stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"-encoding", "ebcdic", "filter"}); err == nil {
+		t.Errorf("Expected an error for an unknown encoding")
+	}
+}
+
+func TestFormatWithBOM(t *testing.T) {
+	chunks, err := formatChunks([]byte("\xef\xbb\xbf[Event \"E\"]\n\n1. e4 *\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].formatted != "[Event \"E\"]\n\n1. e4 *\n\n" {
+		t.Errorf("Unexpected chunks %+v", chunks)
+	}
+}
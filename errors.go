@@ -2,8 +2,9 @@ package main
 
 // Custom error types for different PGN errors
 type PGNError struct {
-	msg string
-	pos int // position where error occurred
+	msg  string
+	pos  int    // position where error occurred
+	hint string // suggested fix, if any
 }
 
 func (e *PGNError) Error() string {
@@ -20,12 +21,50 @@ func (e *PGNError) Is(target error) bool {
 }
 
 var (
-	ErrUnterminatedComment = func(pos int) error { return &PGNError{"unterminated comment", pos} }
-	ErrUnterminatedTag     = func(pos int) error { return &PGNError{"unterminated tag", pos} }
-	ErrUnterminatedQuote   = func(pos int) error { return &PGNError{"unterminated quote", pos} }
-	ErrUnterminatedRAV     = func(pos int) error { return &PGNError{"unterminated variation", pos} }
-	ErrInvalidCommand      = func(pos int) error { return &PGNError{"invalid command in comment", pos} }
-	ErrInvalidPiece        = func(pos int) error { return &PGNError{"invalid piece", pos} }
-	ErrInvalidSquare       = func(pos int) error { return &PGNError{"invalid square", pos} }
-	ErrInvalidRank         = func(pos int) error { return &PGNError{"invalid rank", pos} }
+	ErrUnterminatedComment = func(pos int) error { return &PGNError{msg: "unterminated comment", pos: pos} }
+	ErrUnterminatedTag     = func(pos int) error { return &PGNError{msg: "unterminated tag", pos: pos} }
+	ErrUnterminatedQuote   = func(pos int) error { return &PGNError{msg: "unterminated quote", pos: pos} }
+	ErrUnterminatedRAV     = func(pos int) error { return &PGNError{msg: "unterminated variation", pos: pos} }
+	ErrInvalidCommand      = func(pos int) error { return &PGNError{msg: "invalid command in comment", pos: pos} }
+	ErrInvalidPiece        = func(pos int) error { return &PGNError{msg: "invalid piece", pos: pos} }
+	ErrInvalidSquare       = func(pos int) error { return &PGNError{msg: "invalid square", pos: pos} }
+	ErrInvalidRank         = func(pos int) error { return &PGNError{msg: "invalid rank", pos: pos} }
+	ErrInvalidFEN          = func(pos int) error { return &PGNError{msg: "invalid FEN", pos: pos} }
+	ErrIllegalMove         = func(pos int) error { return &PGNError{msg: "illegal move", pos: pos} }
+	ErrAmbiguousMove       = func(pos int) error { return &PGNError{msg: "ambiguous move", pos: pos} }
+	ErrUnexpectedToken     = func(pos int) error { return &PGNError{msg: "unexpected token", pos: pos} }
+	ErrUnexpectedCharacter = func(pos int) error { return &PGNError{msg: "unexpected character", pos: pos} }
+	ErrUnknownCommand      = func(pos int) error { return &PGNError{msg: "unknown command", pos: pos} }
+	ErrInvalidCommandParam = func(pos int) error { return &PGNError{msg: "invalid command parameter", pos: pos} }
+	ErrInvalidTagValue     = func(pos int) error { return &PGNError{msg: "invalid tag value", pos: pos} }
+	ErrMissingTag          = func(pos int) error { return &PGNError{msg: "missing tag", pos: pos} }
+	ErrMultipleGames       = func(pos int) error { return &PGNError{msg: "more than one game", pos: pos} }
 )
+
+// Pos returns the byte offset in the input where the error occurred
+func (e *PGNError) Pos() int {
+	return e.pos
+}
+
+// Hint returns a suggested fix for the error, such as "did you mean O-O?",
+// or an empty string
+func (e *PGNError) Hint() string {
+	return e.hint
+}
+
+// withPos returns a copy of a PGNError located at pos, other errors are returned as is
+func withPos(err error, pos int) error {
+	if e, ok := err.(*PGNError); ok {
+		return &PGNError{msg: e.msg, pos: pos, hint: e.hint}
+	}
+	return err
+}
+
+// withHint returns a copy of a PGNError with a suggested fix, other errors
+// and empty hints leave err as is
+func withHint(err error, hint string) error {
+	if e, ok := err.(*PGNError); ok && hint != "" {
+		return &PGNError{msg: e.msg, pos: e.pos, hint: hint}
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExplorerMove is a candidate move of a position with the results of the
+// games that played it
+type ExplorerMove struct {
+	Move       Move    `json:"-"`
+	SAN        string  `json:"san"`
+	UCI        string  `json:"uci"`
+	Games      int     `json:"games"`
+	WhiteWins  int     `json:"white_wins"`
+	Draws      int     `json:"draws"`
+	BlackWins  int     `json:"black_wins"`
+	AverageElo float64 `json:"average_elo,omitempty"` // Of both players, over rated ones
+
+	eloSum   int
+	eloCount int
+}
+
+// explorerNode holds the moves played from a position
+type explorerNode struct {
+	moves map[Move]*ExplorerMove
+}
+
+// Explorer aggregates the main lines of games into a tree of positions.
+// Positions are keyed by their hash, so transpositions share a node.
+type Explorer struct {
+	MaxPly int // Plies of each game to add, 0 for all of them
+
+	nodes map[uint64]*explorerNode
+	games int
+}
+
+// NewExplorer creates an empty explorer adding up to maxPly plies per game
+func NewExplorer(maxPly int) *Explorer {
+	return &Explorer{MaxPly: maxPly, nodes: map[uint64]*explorerNode{}}
+}
+
+// Games returns the number of games added
+func (x *Explorer) Games() int {
+	return x.games
+}
+
+// AddGame adds the main line of a game. A move reached several times in the
+// same game, through repetition, only counts once.
+func (x *Explorer) AddGame(g *ParsedGame) {
+	x.games++
+
+	var ratings []int
+	for _, key := range []string{"WhiteElo", "BlackElo"} {
+		value, _ := g.Tag(key)
+		if elo, err := ParseElo(value); err == nil && elo > 0 {
+			ratings = append(ratings, elo)
+		}
+	}
+	result, _ := g.Tag("Result")
+	if g.Result != "*" {
+		result = g.Result
+	}
+
+	type seenMove struct {
+		hash uint64
+		move Move
+	}
+	seen := map[seenMove]bool{}
+	for ply, node := range g.Mainline() {
+		if x.MaxPly > 0 && ply >= x.MaxPly {
+			break
+		}
+		hash := node.Parent.Position.Hash()
+		if seen[seenMove{hash, node.Move}] {
+			continue
+		}
+		seen[seenMove{hash, node.Move}] = true
+
+		n, ok := x.nodes[hash]
+		if !ok {
+			n = &explorerNode{moves: map[Move]*ExplorerMove{}}
+			x.nodes[hash] = n
+		}
+		m, ok := n.moves[node.Move]
+		if !ok {
+			m = &ExplorerMove{Move: node.Move, SAN: node.SAN, UCI: node.Move.UCI()}
+			n.moves[node.Move] = m
+		}
+
+		m.Games++
+		switch result {
+		case "1-0":
+			m.WhiteWins++
+		case "0-1":
+			m.BlackWins++
+		case "1/2-1/2":
+			m.Draws++
+		}
+		for _, elo := range ratings {
+			m.eloSum += elo
+			m.eloCount++
+		}
+	}
+}
+
+// Lookup returns the moves played from a position, most played first
+func (x *Explorer) Lookup(p *Position) []ExplorerMove {
+	n, ok := x.nodes[p.Hash()]
+	if !ok {
+		return nil
+	}
+	moves := make([]ExplorerMove, 0, len(n.moves))
+	for _, m := range n.moves {
+		move := *m
+		if m.eloCount > 0 {
+			move.AverageElo = roundTo(float64(m.eloSum)/float64(m.eloCount), 1)
+		}
+		moves = append(moves, move)
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		if moves[i].Games != moves[j].Games {
+			return moves[i].Games > moves[j].Games
+		}
+		return moves[i].UCI < moves[j].UCI
+	})
+	return moves
+}
+
+// LookupFEN returns the moves played from a position given as FEN
+func (x *Explorer) LookupFEN(fen string) ([]ExplorerMove, error) {
+	p, err := ParseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	return x.Lookup(p), nil
+}
+
+// LookupMoves returns the moves played after a sequence of SAN or UCI moves
+// from the starting position, such as "e4 e5 Nf3"
+func (x *Explorer) LookupMoves(moves string) ([]ExplorerMove, error) {
+	p, err := PlayMoves(NewPosition(), moves)
+	if err != nil {
+		return nil, err
+	}
+	return x.Lookup(p), nil
+}
+
+// PlayMoves plays a space-separated sequence of SAN or UCI moves, with
+// optional move numbers, from a position
+func PlayMoves(p *Position, moves string) (*Position, error) {
+	for _, word := range strings.Fields(moves) {
+		// Drop move numbers such as 1. or 1... and 1.e4
+		if i := strings.LastIndexByte(word, '.'); i >= 0 {
+			word = word[i+1:]
+		}
+		if word == "" {
+			continue
+		}
+		m, err := p.ParseSAN(word)
+		if err != nil {
+			var uciErr error
+			if m, uciErr = p.ParseUCI(word); uciErr != nil {
+				return nil, fmt.Errorf("move %s: %w", word, err)
+			}
+		}
+		p = p.Play(m)
+	}
+	return p, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPositionHash(t *testing.T) {
+	play := func(moves string) *Position {
+		p, err := PlayMoves(NewPosition(), moves)
+		if err != nil {
+			t.Fatalf("%s: %v", moves, err)
+		}
+		return p
+	}
+
+	if play("Nf3 Nf6 Nc3").Hash() != play("Nc3 Nf6 Nf3").Hash() {
+		t.Errorf("Expected transpositions to hash the same")
+	}
+	if play("Nf3 Nf6 Ng1 Ng8").Hash() != NewPosition().Hash() {
+		t.Errorf("Expected move counters to be ignored")
+	}
+	if play("e4").Hash() == play("e3 e6 e4").Hash() {
+		t.Errorf("Expected the side to move to change the hash")
+	}
+	if play("e4 Nf6 Ke2 Ng8 Ke1 Nf6").Hash() == play("e4 Nf6").Hash() {
+		t.Errorf("Expected castling rights to change the hash")
+	}
+	// e4 allows no en passant capture, so it is not part of the position
+	withFEN, _ := ParseFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if withFEN.Hash() != play("e4").Hash() {
+		t.Errorf("Expected an impossible en passant square to be ignored")
+	}
+	if play("e4 a6 e5 d5").Hash() == play("e4 d6 e5 d5").Hash() {
+		t.Errorf("Expected a possible en passant capture to change the hash")
+	}
+}
+
+func TestExplorer(t *testing.T) {
+	games := []string{
+		"[Result \"1-0\"]\n[WhiteElo \"2500\"]\n[BlackElo \"2300\"]\n1. Nf3 Nf6 2. Nc3 d5 1-0",
+		"[Result \"0-1\"]\n1. Nc3 Nf6 2. Nf3 e6 0-1",
+		"[Result \"1/2-1/2\"]\n1. Nf3 Nf6 2. Nc3 d5 1/2-1/2",
+		"[Result \"1-0\"]\n1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 1-0",
+	}
+	x := NewExplorer(0)
+	for _, pgn := range games {
+		g, err := ParseGame(&Game{Raw: pgn})
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.AddGame(g)
+	}
+
+	moves := x.Lookup(NewPosition())
+	if len(moves) != 2 || moves[0].SAN != "Nf3" || moves[0].Games != 3 || moves[1].SAN != "Nc3" {
+		t.Fatalf("Unexpected moves from the start %+v", moves)
+	}
+	nf3 := moves[0]
+	if nf3.WhiteWins != 2 || nf3.Draws != 1 || nf3.BlackWins != 0 || nf3.AverageElo != 2400 || nf3.UCI != "g1f3" {
+		t.Errorf("Unexpected statistics for Nf3 %+v", nf3)
+	}
+
+	// Both move orders reach the same position
+	moves, err := x.LookupMoves("1. Nc3 Nf6 2. Nf3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 2 || moves[0].SAN != "d5" || moves[0].Games != 2 || moves[1].SAN != "e6" {
+		t.Errorf("Expected transpositions to merge, got %+v", moves)
+	}
+
+	moves, err = x.LookupFEN("r1bqkbnr/pppppppp/2n5/8/8/2N5/PPPPPPPP/R1BQKBNR w KQkq - 2 2")
+	if err != nil || moves != nil {
+		t.Errorf("Expected no moves for an unknown position, got %+v, %v", moves, err)
+	}
+	if _, err := x.LookupMoves("e4 e4"); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+
+	shallow := NewExplorer(1)
+	g, _ := ParseGame(&Game{Raw: games[0]})
+	shallow.AddGame(g)
+	if moves, _ := shallow.LookupMoves("Nf3"); moves != nil {
+		t.Errorf("Expected the depth to limit the tree, got %+v", moves)
+	}
+}
+
+func TestExploreCommand(t *testing.T) {
+	input := "[Event \"A\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Nf3 1-0\n\n[Event \"B\"]\n[Result \"0-1\"]\n\n1. e4 c5 0-1\n"
+	want := "move  games  white  draws  black  average_elo\nc5    1      0%     0%     100%   \ne5    1      100%   0%     0%     \n"
+	// Without Event tags the scanner delivers both games together
+	for _, input := range []string{input, strings.ReplaceAll(input, "[Event", "[Round")} {
+		var stdout bytes.Buffer
+		e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+		if err := run(e, []string{"explore", "-moves", "e4"}); err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != want {
+			t.Errorf("Expected:\n%q\ngot:\n%q", want, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GameFilter reports whether a game, given as its syntax node, is selected.
+// Filters only look at tags and movetext, so games are not replayed.
+type GameFilter func(game *SyntaxNode) bool
+
+// AllOf selects games matching every filter
+func AllOf(filters ...GameFilter) GameFilter {
+	return func(game *SyntaxNode) bool {
+		for _, f := range filters {
+			if !f(game) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// tagOperators are the comparisons of a tag filter, longest first so that
+// ">=" is not read as ">"
+var tagOperators = []string{"=~", "!=", ">=", "<=", "=", "~", ">", "<"}
+
+// ParseTagFilter parses a condition on a tag:
+//
+//	Key=value    exact value
+//	Key!=value   any other value, or no tag
+//	Key~glob     shell pattern such as *Carlsen*
+//	Key=~regexp  regular expression
+//	Key>n, Key>=n, Key<n, Key<=n
+//	             numeric comparison, or date comparison for tags ending in
+//	             Date where the bound may be partial (2020 or 2020.06)
+func ParseTagFilter(expr string) (GameFilter, error) {
+	i := 0
+	for i < len(expr) && (isAlphaNumeric(expr[i]) || expr[i] == '_') {
+		i++
+	}
+	key, rest := expr[:i], expr[i:]
+	op := ""
+	for _, candidate := range tagOperators {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if key == "" || op == "" {
+		return nil, fmt.Errorf("invalid tag filter %q", expr)
+	}
+	value := rest[len(op):]
+
+	var match func(string) bool
+	switch op {
+	case "=":
+		match = func(s string) bool { return s == value }
+	case "!=":
+		return func(game *SyntaxNode) bool {
+			s, ok := game.Tag(key)
+			return !ok || s != value
+		}, nil
+	case "~":
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern in tag filter %q: %v", expr, err)
+		}
+		match = func(s string) bool {
+			ok, _ := path.Match(value, s)
+			return ok
+		}
+	case "=~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp in tag filter %q: %v", expr, err)
+		}
+		match = re.MatchString
+	default:
+		compare, err := tagComparison(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound in tag filter %q", expr)
+		}
+		match = func(s string) bool {
+			c, ok := compare(s)
+			switch op {
+			case ">":
+				return ok && c > 0
+			case ">=":
+				return ok && c >= 0
+			case "<":
+				return ok && c < 0
+			default:
+				return ok && c <= 0
+			}
+		}
+	}
+
+	return func(game *SyntaxNode) bool {
+		s, ok := game.Tag(key)
+		return ok && match(s)
+	}, nil
+}
+
+// Helper to build a function comparing tag values to a bound, reporting false
+// when the value cannot be compared
+func tagComparison(key, bound string) (func(string) (int, bool), error) {
+	if strings.HasSuffix(key, "Date") {
+		b, err := parseDateBound(bound)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) (int, bool) {
+			d, err := ParseDate(s)
+			if err != nil || d.Year == 0 {
+				return 0, false
+			}
+			return compareDateBound(d, b), true
+		}, nil
+	}
+
+	b, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(s string) (int, bool) {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case n < b:
+			return -1, true
+		case n > b:
+			return 1, true
+		}
+		return 0, true
+	}, nil
+}
+
+// parseDateBound parses a full or partial date such as 2020.06.01, 2020.06 or 2020
+func parseDateBound(s string) (Date, error) {
+	parts := strings.Split(s, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "??")
+	}
+	return ParseDate(strings.Join(parts, "."))
+}
+
+// Helper to compare dates component by component, unknown ones counting as 0
+func compareDates(a, b Date) int {
+	for _, pair := range [][2]int{{a.Year, b.Year}, {a.Month, b.Month}, {a.Day, b.Day}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Helper to compare a date to a bound only up to the precision of the bound,
+// so that 2020.06.01 is equal to the bounds 2020.06 and 2020
+func compareDateBound(d, bound Date) int {
+	switch {
+	case bound.Month == 0:
+		d.Month, d.Day = 0, 0
+	case bound.Day == 0:
+		d.Day = 0
+	}
+	return compareDates(d, bound)
+}
+
+// PlayerFilter selects games where White or Black matches a shell pattern. If
+// minElo is positive, that player must also be rated at least minElo.
+func PlayerFilter(pattern string, minElo int) (GameFilter, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid player pattern %q: %v", pattern, err)
+	}
+	return func(game *SyntaxNode) bool {
+		for _, side := range []string{"White", "Black"} {
+			name, _ := game.Tag(side)
+			if ok, _ := path.Match(pattern, name); !ok {
+				continue
+			}
+			if minElo <= 0 {
+				return true
+			}
+			elo, _ := game.Tag(side + "Elo")
+			if n, err := ParseElo(elo); err == nil && n >= minElo {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// EloFilter selects games where both players are rated at least minElo
+func EloFilter(minElo int) GameFilter {
+	return func(game *SyntaxNode) bool {
+		for _, key := range []string{"WhiteElo", "BlackElo"} {
+			elo, _ := game.Tag(key)
+			if n, err := ParseElo(elo); err != nil || n < minElo {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ResultFilter selects games ending with one of the results. The movetext
+// result is used, or the Result tag when the movetext has none.
+func ResultFilter(results ...string) (GameFilter, error) {
+	for _, r := range results {
+		if !isResult(r) {
+			return nil, fmt.Errorf("invalid result %q", r)
+		}
+	}
+	return func(game *SyntaxNode) bool {
+		result := ""
+		if leaf := gameResult(game); leaf != nil {
+			result = leaf.Token.Value
+		} else {
+			result, _ = game.Tag("Result")
+		}
+		for _, r := range results {
+			if r == result {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// PlyFilter selects games whose main line has between min and max plies.
+// A negative max means no upper bound.
+func PlyFilter(min, max int) GameFilter {
+	return func(game *SyntaxNode) bool {
+		n := plyCount(game)
+		return n >= min && (max < 0 || n <= max)
+	}
+}
+
+// plyCount counts the moves of the main line of a game node
+func plyCount(game *SyntaxNode) int {
+	n := 0
+	for _, child := range game.Children {
+		if child.Kind == SyntaxMove {
+			n++
+		}
+	}
+	return n
+}
+
+// ECOFilter selects games whose ECO tag is in a range such as B20-B99, or
+// equal to a single code such as C50
+func ECOFilter(codes string) (GameFilter, error) {
+	from, to, isRange := strings.Cut(codes, "-")
+	if !isRange {
+		to = from
+	}
+	if _, err := ParseECO(from); err != nil {
+		return nil, fmt.Errorf("invalid ECO range %q", codes)
+	}
+	if _, err := ParseECO(to); err != nil || to < from {
+		return nil, fmt.Errorf("invalid ECO range %q", codes)
+	}
+	return func(game *SyntaxNode) bool {
+		eco, _ := game.Tag("ECO")
+		if _, err := ParseECO(eco); err != nil {
+			return false
+		}
+		return eco >= from && eco <= to
+	}, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func filterGame(t *testing.T, pgn string) *SyntaxNode {
+	t.Helper()
+	games := ParseSyntax(pgn).Games()
+	if len(games) != 1 {
+		t.Fatalf("Expected 1 game, got %d", len(games))
+	}
+	return games[0]
+}
+
+func TestTagFilters(t *testing.T) {
+	game := filterGame(t, `[Event "Tata Steel Masters"]
+[Date "2021.01.17"]
+[White "Carlsen, Magnus"]
+[Black "Giri, Anish"]
+[WhiteElo "2862"]
+[BlackElo "2764"]
+[ECO "B90"]
+[Result "1/2-1/2"]
+
+1. e4 c5 2. Nf3 d6 1/2-1/2`)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"White=Carlsen, Magnus", true},
+		{"White=Carlsen", false},
+		{"White!=Carlsen", true},
+		{"Annotator!=Anyone", true},
+		{"Event~*Steel*", true},
+		{"Event~Steel*", false},
+		{"Black=~^Giri", true},
+		{"Black=~^giri", false},
+		{"WhiteElo>2800", true},
+		{"BlackElo>=2800", false},
+		{"BlackElo<2764", false},
+		{"BlackElo<=2764", true},
+		{"Date>=2020", true},
+		{"Date<2021.01", false},
+		{"Date<2021.01.18", true},
+		// Partial bounds cover their whole year or month
+		{"Date<=2021", true},
+		{"Date>2021", false},
+		{"Date<2021", false},
+		{"Date<=2021.01", true},
+		{"Date>2021.01", false},
+		{"Date>2020.12", true},
+		{"Date<=2020.12", false},
+		{"Round>1", false}, // Missing tag
+	}
+	for _, tt := range tests {
+		f, err := ParseTagFilter(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.expr, err)
+			continue
+		}
+		if got := f(game); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{"White", "=Carlsen", "WhiteElo>high", "Date>2020.13", "White=~(", "White~["} {
+		if _, err := ParseTagFilter(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
+
+func TestGameFilters(t *testing.T) {
+	game := filterGame(t, `[White "Carlsen, Magnus"]
+[Black "Giri, Anish"]
+[WhiteElo "2862"]
+[BlackElo "2764"]
+[ECO "B90"]
+[Result "1-0"]
+
+1. e4 c5 (1... e5 2. Nf3) 2. Nf3 d6 1/2-1/2`)
+
+	player := func(pattern string, elo int) GameFilter {
+		f, err := PlayerFilter(pattern, elo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	result := func(results ...string) GameFilter {
+		f, err := ResultFilter(results...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	eco := func(codes string) GameFilter {
+		f, err := ECOFilter(codes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	tests := []struct {
+		name   string
+		filter GameFilter
+		want   bool
+	}{
+		{"player as black", player("Giri*", 0), true},
+		{"player rated", player("Giri*", 2750), true},
+		{"player underrated", player("Giri*", 2800), false},
+		{"unknown player", player("Nakamura*", 0), false},
+		{"both rated", EloFilter(2750), true},
+		{"both rated higher", EloFilter(2800), false},
+		{"movetext result", result("1/2-1/2"), true},
+		{"result tag ignored", result("1-0", "0-1"), false},
+		{"plies", PlyFilter(4, 4), true},
+		{"too few plies", PlyFilter(5, -1), false},
+		{"eco range", eco("B20-B99"), true},
+		{"eco code", eco("B90"), true},
+		{"eco outside", eco("C00-C99"), false},
+		{"all of", AllOf(player("Carlsen*", 2800), eco("B20-B99"), PlyFilter(1, -1)), true},
+		{"all of failing", AllOf(player("Carlsen*", 2800), eco("A00")), false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter(game); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := ECOFilter("B99-B20"); err == nil {
+		t.Errorf("Expected an error for a reversed ECO range")
+	}
+	if _, err := ResultFilter("win"); err == nil {
+		t.Errorf("Expected an error for an invalid result")
+	}
+}
+
+func TestFilterCommand(t *testing.T) {
+	input := "[Event \"A\"]\n[White \"X\"]\n[WhiteElo \"2500\"]\n\n1. e4   e5 1-0\n\n\n" +
+		"[Event \"B\"]\n[White \"Y\"]\n[WhiteElo \"2300\"]\n\n1. d4 0-1\n"
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"filter", "-tag", "WhiteElo>2400", "-result", "1-0"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[Event \"A\"]\n[White \"X\"]\n[WhiteElo \"2500\"]\n\n1. e4   e5 1-0\n\n"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"filter", "-v", "-player", "X"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stdout.String(), "[Event \"B\"]") || strings.Count(stdout.String(), "[Event") != 1 {
+		t.Errorf("Expected only game B, got %q", stdout.String())
+	}
+
+	// Unreadable text is reported and the game is written unchanged
+	input = "[Event \"A\"]\n\n1. e4 e5 {unterminated\n"
+	for _, args := range [][]string{{"filter"}, {"filter", "-v", "-result", "0-1"}} {
+		stdout.Reset()
+		stderr.Reset()
+		e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+		if err := run(e, args); err != errFailed {
+			t.Errorf("%v: expected the command to fail, got %v", args, err)
+		}
+		if stdout.String() != strings.TrimSpace(input)+"\n\n" || !strings.Contains(stderr.String(), "unterminated comment") {
+			t.Errorf("%v: unexpected output %q, errors %q", args, stdout.String(), stderr.String())
+		}
+	}
+}
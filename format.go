@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// formatChunk is a part of an input and its export format. Each chunk holds
+// a scanned game along with the whitespace that follows it, so that diffs are
+// computed game by game.
+type formatChunk struct {
+	src       string
+	formatted string
+}
+
+// FormatPGN rewrites a PGN input in export format: Seven Tag Roster first,
+// normalised whitespace, move numbers and line wrapping. Comments, NAGs,
+// commands and variations are kept. Inputs with text that cannot be parsed
+// are rejected rather than losing part of it.
+func FormatPGN(src []byte) ([]byte, error) {
+	chunks, err := formatChunks(src)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, chunk := range chunks {
+		buf.WriteString(chunk.formatted)
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatGame returns a game in export format with its tags in canonical order
+func FormatGame(g *ParsedGame) string {
+	formatted := *g
+	formatted.Tags = canonicalTags(g.Tags)
+	return formatted.String()
+}
+
+// canonicalTags orders tags with the Seven Tag Roster first, in its standard
+// order, followed by the other tags as they were
+func canonicalTags(tags []Tag) []Tag {
+	ordered := make([]Tag, 0, len(tags))
+	for _, key := range sevenTagRoster {
+		for _, tag := range tags {
+			if tag.Key == key {
+				ordered = append(ordered, tag)
+			}
+		}
+	}
+	for _, tag := range tags {
+		if !isRosterTag(tag.Key) {
+			ordered = append(ordered, tag)
+		}
+	}
+	return ordered
+}
+
+func isRosterTag(key string) bool {
+	for _, k := range sevenTagRoster {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper to split an input into its games and format each of them
+func formatChunks(src []byte) ([]formatChunk, error) {
+	var chunks []formatChunk
+	var starts []int64
+	scanner := NewScanner(bytes.NewReader(src))
+	end := int64(0) // End of the last game scanned
+	if bytes.HasPrefix(src, utf8BOM) {
+		end = int64(len(utf8BOM))
+	}
+
+	for {
+		game, err := scanner.ScanGame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBlank(src, end, game.Offset); err != nil {
+			return nil, err
+		}
+
+		g, err := ParseGame(game)
+		if err != nil {
+			return nil, &gameError{game, err}
+		}
+
+		chunks = append(chunks, formatChunk{formatted: FormatGame(g)})
+		starts = append(starts, game.Offset)
+		end = game.Offset + int64(game.Size)
+	}
+	if err := checkBlank(src, end, int64(len(src))); err != nil {
+		return nil, err
+	}
+
+	// Each chunk runs to the start of the next one, the first from the start
+	for i := range chunks {
+		from, to := int64(0), int64(len(src))
+		if i > 0 {
+			from = starts[i]
+		}
+		if i+1 < len(chunks) {
+			to = starts[i+1]
+		}
+		chunks[i].src = string(src[from:to])
+	}
+	return chunks, nil
+}
+
+// checkBlank reports text between games, which the scanner skips
+func checkBlank(src []byte, from, to int64) error {
+	gap := src[from:to]
+	if i := bytes.IndexFunc(gap, func(r rune) bool { return r > ' ' }); i >= 0 {
+		line := 1 + bytes.Count(src[:from+int64(i)], []byte{'\n'})
+		return fmt.Errorf("line %d: text outside of a game", line)
+	}
+	return nil
+}
+
+// gameError is an error in a scanned game, prefixed with its line in the
+// input when printed
+type gameError struct {
+	game *Game
+	err  error
+}
+
+func (e *gameError) Error() string {
+	line := max(e.game.Line, 1)
+	if pgnErr, ok := e.err.(*PGNError); ok {
+		line += strings.Count(e.game.Raw[:min(max(pgnErr.Pos(), 0), len(e.game.Raw))], "\n")
+	}
+	return fmt.Sprintf("line %d: %v", line, e.err)
+}
+
+func (e *gameError) Unwrap() error {
+	return e.err
+}
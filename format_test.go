@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatPGN(t *testing.T) {
+	src := "[White \"W\"]\n[Event \"E\"]\n[ECO \"C50\"]\n[Result \"*\"]\n\n1.e4  e5 2.Nf3 {a\n comment} ( 2.Nc3 ) Nc6!  *\n\n\n" +
+		"[Event \"F\"]\n1. d4 *"
+	want := "[Event \"E\"]\n[White \"W\"]\n[Result \"*\"]\n[ECO \"C50\"]\n\n1. e4 e5 2. Nf3 {a comment} (2. Nc3) 2... Nc6 $1 *\n\n" +
+		"[Event \"F\"]\n\n1. d4 *\n\n"
+
+	got, err := FormatPGN([]byte(src))
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	again, err := FormatPGN(got)
+	if err != nil || !bytes.Equal(again, got) {
+		t.Errorf("Formatting is not idempotent: %q", again)
+	}
+}
+
+func TestFormatTrailingComment(t *testing.T) {
+	// A comment after the result is kept with its game, not made a game of
+	src := "[Event \"A\"]\n\n1. e4 e5 1-0 {trailing comment}\n"
+	want := "[Event \"A\"]\n\n1. e4 e5 {trailing comment} 1-0\n\n"
+	got, err := FormatPGN([]byte(src))
+	if err != nil || string(got) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s (%v)", want, got, err)
+	}
+}
+
+func TestFormatFixtures(t *testing.T) {
+	for _, name := range []string{"single_game.pgn", "multi_game.pgn", "annotated_game.pgn"} {
+		data, err := os.ReadFile(filepath.Join("fixtures", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := FormatPGN(data)
+		if err != nil {
+			t.Fatalf("%s: failed to format: %v", name, err)
+		}
+		twice, err := FormatPGN(once)
+		if err != nil || !bytes.Equal(once, twice) {
+			t.Errorf("%s: formatting is not idempotent", name)
+		}
+		if strings.Count(string(once), "[Event ") != strings.Count(string(data), "[Event ") {
+			t.Errorf("%s: games were lost", name)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"illegal move", "[Event \"E\"]\n\n1. e4 e5\n2. Ke3 *", "line 4: illegal move"},
+		{"unterminated comment", "[Event \"E\"]\n1. e4 {open *", "line 2: unterminated comment"},
+		{"text before games", "junk\n[Event \"E\"]\n1. e4 *", "line 1: text outside of a game"},
+		{"unterminated comment without Event", "[White \"A\"]\n1. e4 *\n\n[White \"B\"]\n1. d4 {open *", "line 5: unterminated comment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FormatPGN([]byte(tt.input)); err == nil || err.Error() != tt.err {
+				t.Errorf("Expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n"
+	var buf bytes.Buffer
+	if err := writeUnifiedDiff(&buf, "a", "b", diffLines(a, b)); err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a\n+++ b\n@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n"
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeUnifiedDiff(&buf, "a", "b", diffLines(a, a)); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected no diff for equal inputs, got %q", buf.String())
+	}
+}
+
+func TestFormatChunks(t *testing.T) {
+	// Games are diffed one by one even without an Event tag between them
+	src := "[White \"A\"]\n1.e4 *\n\n[White \"B\"]\n1.d4 *\n"
+	chunks, err := formatChunks([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].src+chunks[1].src != src || chunks[1].formatted != "[White \"B\"]\n\n1. d4 *\n\n" {
+		t.Errorf("Expected a chunk per game, got %+v", chunks)
+	}
+}
+
+func TestFmtCommand(t *testing.T) {
+	dir := t.TempDir()
+	messy := filepath.Join(dir, "messy.pgn")
+	clean := filepath.Join(dir, "clean.pgn")
+	os.WriteFile(messy, []byte("[Event \"E\"]\n1.e4 *\n"), 0o644)
+	os.WriteFile(clean, []byte("[Event \"E\"]\n\n1. e4 *\n\n"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	e := &env{stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"fmt", "-l", messy, clean}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != messy+"\n" {
+		t.Errorf("Expected only %s to be listed, got %q", messy, stdout.String())
+	}
+
+	if err := run(e, []string{"fmt", "-w", messy}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(messy); string(data) != "[Event \"E\"]\n\n1. e4 *\n\n" {
+		t.Errorf("Unexpected rewritten file %q", data)
+	}
+	// Errors in games are reported with the line they are on
+	e = &env{stdin: strings.NewReader("[Event \"E\"]\n\n1. e4 e5\n2. Nbf3 *\n"), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"fmt"}); err != errFailed {
+		t.Errorf("Expected the illegal move to fail the command, got %v", err)
+	}
+	want := "<stdin>:4:4: error: illegal move\n 4 | 2. Nbf3 *\n   |    ^~~~\n   = hint: did you mean \"Nf3\"?\n"
+	if stderr.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stderr.String())
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Tag is a tag pair such as [Event "Example"]
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Command is an embedded command such as [%clk 0:05:00]
+type Command struct {
+	Name   string
+	Params []string
+	Pos    int // Byte offset of the command in the game text
+}
+
+// MoveNode is a node of the game tree. The root holds the starting position
+// and every other node the move that led to its position. The first child
+// continues the line, any further children are variations replacing it.
+type MoveNode struct {
+	Parent   *MoveNode
+	Children []*MoveNode
+
+	Move     Move
+	SAN      string
+	Position *Position // Position after the move
+
+	StartingComments []string // Comments before the first move of a variation
+	Comments         []string // Comments after the move
+	Commands         []Command
+	NAGs             []int
+}
+
+// ParsedGame is a game with its tags, move tree and result
+type ParsedGame struct {
+	Tags   []Tag
+	Root   *MoveNode
+	Result string
+}
+
+// NewParsedGame creates an empty game starting from a position
+func NewParsedGame(start *Position) *ParsedGame {
+	return &ParsedGame{Root: &MoveNode{Position: start}, Result: "*"}
+}
+
+// Tag returns the value of a tag
+func (g *ParsedGame) Tag(key string) (string, bool) {
+	for _, tag := range g.Tags {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetTag changes the value of a tag, adding it at the end if it is missing
+func (g *ParsedGame) SetTag(key, value string) {
+	for i := range g.Tags {
+		if g.Tags[i].Key == key {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Key: key, Value: value})
+}
+
+// RemoveTag deletes a tag, reporting whether it was present
+func (g *ParsedGame) RemoveTag(key string) bool {
+	for i, tag := range g.Tags {
+		if tag.Key == key {
+			g.Tags = append(g.Tags[:i], g.Tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Mainline returns the nodes of the main line, without the root
+func (g *ParsedGame) Mainline() []*MoveNode {
+	var nodes []*MoveNode
+	for node := g.Root.Next(); node != nil; node = node.Next() {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Next returns the main continuation of a node, or nil at the end of a line
+func (n *MoveNode) Next() *MoveNode {
+	if len(n.Children) == 0 {
+		return nil
+	}
+	return n.Children[0]
+}
+
+// Ply returns the number of moves from the root to the node
+func (n *MoveNode) Ply() int {
+	ply := 0
+	for node := n; node.Parent != nil; node = node.Parent {
+		ply++
+	}
+	return ply
+}
+
+// AddChild plays a legal move from the node's position and appends it as a
+// new child. The first child added becomes the main continuation.
+func (n *MoveNode) AddChild(m Move) *MoveNode {
+	child := &MoveNode{
+		Parent:   n,
+		Move:     m,
+		SAN:      n.Position.SAN(m),
+		Position: n.Position.Play(m),
+	}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// ParseGame parses a scanned game into a tree of moves, replaying each move
+// so that every node knows its position. It fails on text holding several
+// games, which the scanner returns one at a time.
+func ParseGame(game *Game) (*ParsedGame, error) {
+	if game == nil {
+		return nil, nil
+	}
+
+	file := ParseSyntax(game.Raw)
+	if err := trailingError(file); err != nil {
+		return nil, err
+	}
+
+	games := file.Games()
+	switch len(games) {
+	case 0:
+		return NewParsedGame(NewPosition()), nil
+	case 1:
+		return parseGameSyntax(games[0], nil)
+	}
+	return nil, ErrMultipleGames(games[1].firstLeaf().Token.Pos)
+}
+
+// Helper to report the text after the last game of a syntax tree that no
+// game holds, such as an unterminated comment
+func trailingError(file *SyntaxNode) error {
+	eof := file.Children[len(file.Children)-1]
+	if eof.Token.Error != nil {
+		return eof.Token.Error
+	} else if eof.Raw != "" {
+		return ErrUnexpectedToken(len(file.String()) - len(eof.Raw))
+	}
+	return nil
+}
+
+// gameParser builds a game tree from a game syntax node
+type gameParser struct {
+	game  *ParsedGame
+	moves map[*SyntaxNode]*MoveNode // If not nil, records the tree node of each move node
+}
+
+// Helper to build a game from its syntax node, optionally recording which
+// tree node each move became
+func parseGameSyntax(node *SyntaxNode, moves map[*SyntaxNode]*MoveNode) (*ParsedGame, error) {
+	g := &ParsedGame{}
+	for _, child := range node.Children {
+		if child.Kind == SyntaxTag {
+			value := ""
+			if leaf := child.leaf(TAG_VALUE); leaf != nil {
+				value = leaf.Token.Value
+			}
+			g.Tags = append(g.Tags, Tag{Key: child.tagKey(), Value: value})
+		}
+	}
+
+	start := NewPosition()
+	if fen, ok := g.Tag("FEN"); ok {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			return nil, withPos(err, node.findTag("FEN").firstLeaf().Token.Pos)
+		}
+		start = p
+	}
+	g.Root = &MoveNode{Position: start}
+
+	gp := &gameParser{game: g, moves: moves}
+	if err := gp.parseLine(node.Children, g.Root, false); err != nil {
+		return nil, err
+	}
+
+	if g.Result == "" {
+		g.Result = "*"
+		if result, ok := g.Tag("Result"); ok && isResult(result) {
+			g.Result = result
+		}
+	}
+	return g, nil
+}
+
+// parseLine adds the moves of a line to the tree. start is the node the line
+// continues from, which for a variation is the parent of the move it replaces.
+func (gp *gameParser) parseLine(nodes []*SyntaxNode, start *MoveNode, variation bool) error {
+	cur := start
+	var pendingComments []string
+	var pendingCommands []Command
+
+	for _, node := range nodes {
+		switch node.Kind {
+		case SyntaxTag:
+			// Already collected
+
+		case SyntaxMove:
+			first := node.firstLeaf().Token
+			var sb strings.Builder
+			for _, leaf := range node.Children {
+				if leaf.Token.Error != nil {
+					return leaf.Token.Error
+				}
+				sb.WriteString(leaf.Token.Value)
+			}
+			m, err := cur.Position.ParseSAN(sb.String())
+			if err != nil {
+				return withPos(withHint(err, moveHint(cur.Position, sb.String())), first.Pos)
+			}
+			cur = cur.AddChild(m)
+			if gp.moves != nil {
+				gp.moves[node] = cur
+			}
+			cur.StartingComments, pendingComments = pendingComments, nil
+			cur.Commands, pendingCommands = pendingCommands, nil
+
+		case SyntaxComment:
+			text, commands := commentContent(node)
+			if variation && cur == start {
+				if text != "" {
+					pendingComments = append(pendingComments, text)
+				}
+				pendingCommands = append(pendingCommands, commands...)
+				continue
+			}
+			if text != "" {
+				cur.Comments = append(cur.Comments, text)
+			}
+			cur.Commands = append(cur.Commands, commands...)
+
+		case SyntaxVariation:
+			if cur == start {
+				return ErrUnexpectedToken(node.firstLeaf().Token.Pos)
+			}
+			if err := gp.parseLine(node.Children, cur.Parent, true); err != nil {
+				return err
+			}
+
+		case SyntaxToken:
+			if err := gp.parseToken(node.Token, cur, start, variation); err != nil {
+				return err
+			}
+
+		default:
+			return ErrUnexpectedToken(node.firstLeaf().Token.Pos)
+		}
+	}
+	return nil
+}
+
+func (gp *gameParser) parseToken(tok Token, cur, start *MoveNode, variation bool) error {
+	if tok.Error != nil {
+		return tok.Error
+	}
+
+	switch tok.Type {
+	case MOVE_NUMBER, DOT, ELLIPSIS:
+		// Move numbers are implied by the tree
+	case VARIATION_START, VARIATION_END:
+		// Delimiters of the variation being parsed
+	case NAG:
+		nag, err := strconv.Atoi(strings.TrimPrefix(tok.Value, "$"))
+		if err != nil || cur == start {
+			return ErrUnexpectedToken(tok.Pos)
+		}
+		cur.NAGs = append(cur.NAGs, nag)
+	case ANNOTATION:
+		nag, ok := annotationNAGs[tok.Value]
+		if !ok || cur == start {
+			return ErrUnexpectedToken(tok.Pos)
+		}
+		cur.NAGs = append(cur.NAGs, nag)
+	case RESULT:
+		if variation {
+			return ErrUnexpectedToken(tok.Pos)
+		}
+		gp.game.Result = tok.Value
+	default:
+		return ErrUnexpectedToken(tok.Pos)
+	}
+	return nil
+}
+
+// annotationNAGs maps move suffix annotations to their NAG
+var annotationNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// Helper to extract the text and commands of a comment node
+func commentContent(node *SyntaxNode) (string, []Command) {
+	var parts []string
+	var commands []Command
+	for _, child := range node.Children {
+		switch {
+		case child.Kind == SyntaxToken && child.Token.Type == COMMENT:
+			parts = append(parts, child.Token.Value)
+		case child.Kind == SyntaxCommand:
+			commands = append(commands, commandContent(child))
+		}
+	}
+	return strings.Join(parts, " "), commands
+}
+
+func commandContent(node *SyntaxNode) Command {
+	cmd := Command{Pos: node.firstLeaf().Token.Pos}
+	for _, child := range node.Children {
+		switch child.Token.Type {
+		case COMMAND_NAME:
+			cmd.Name = child.Token.Value
+		case COMMAND_PARAM:
+			cmd.Params = append(cmd.Params, child.Token.Value)
+		}
+	}
+	return cmd
+}
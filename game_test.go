@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadFixtureGame(t *testing.T, name string) *ParsedGame {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("fixtures", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	g, err := ParseGame(&Game{Raw: string(data)})
+	if err != nil {
+		t.Fatalf("Failed to parse fixture %s: %v", name, err)
+	}
+	return g
+}
+
+func TestParseGame(t *testing.T) {
+	g := loadFixtureGame(t, "annotated_game.pgn")
+
+	if g.Result != "1/2-1/2" {
+		t.Errorf("Expected result %q, got %q", "1/2-1/2", g.Result)
+	}
+	if len(g.Tags) != 10 {
+		t.Errorf("Expected 10 tags, got %d", len(g.Tags))
+	}
+	if value, _ := g.Tag("White"); value != "Player1" {
+		t.Errorf("Expected White tag %q, got %q", "Player1", value)
+	}
+	if len(g.Root.Comments) != 1 || g.Root.Comments[0] != "Giuoco Piano" {
+		t.Errorf("Expected game comment, got %q", g.Root.Comments)
+	}
+
+	mainline := g.Mainline()
+	if len(mainline) != 41 {
+		t.Fatalf("Expected 41 moves in the main line, got %d", len(mainline))
+	}
+
+	e4 := mainline[0]
+	if e4.SAN != "e4" || len(e4.Commands) != 1 || e4.Commands[0].Name != "clk" || e4.Commands[0].Params[0] != "1:59:50" {
+		t.Errorf("Unexpected first move %q with commands %v", e4.SAN, e4.Commands)
+	}
+
+	castle := mainline[14]
+	if castle.SAN != "O-O" || len(castle.NAGs) != 1 || castle.NAGs[0] != 1 {
+		t.Errorf("Expected O-O with NAG 1, got %q %v", castle.SAN, castle.NAGs)
+	}
+
+	// 8... Bxc3 has the alternative 8... Nxc3 with its own nested variation
+	bxc3 := mainline[15]
+	if len(bxc3.Parent.Children) != 2 || bxc3.Parent.Children[1].SAN != "Nxc3" {
+		t.Fatalf("Expected variation 8... Nxc3")
+	}
+	nested := bxc3.Parent.Children[1].Next().Next()
+	if nested.SAN != "Bxc3" || len(nested.NAGs) != 1 || nested.NAGs[0] != 2 || len(nested.Parent.Children) != 2 {
+		t.Errorf("Expected nested variation after 9... Bxc3?, got %q", nested.SAN)
+	}
+
+	d5 := mainline[16]
+	if len(d5.Commands) != 2 || len(d5.Comments) != 1 || d5.Comments[0] != "The Moller attack" {
+		t.Errorf("Expected commands and comment on 9. d5, got %v %q", d5.Commands, d5.Comments)
+	}
+
+	if last := mainline[len(mainline)-1]; last.Ply() != 41 || last.Position.FEN() != "r1b2q1Q/ppp1nkp1/3p3R/1B1P1p2/8/8/PP3PPP/4R1K1 b - - 0 21" {
+		t.Errorf("Unexpected final position %q at ply %d", last.Position.FEN(), last.Ply())
+	}
+}
+
+func TestParseGameFromFEN(t *testing.T) {
+	raw := `[SetUp "1"]
+[FEN "8/4P3/8/8/8/8/8/K6k w - - 0 60"]
+
+60. e8=Q+ Kg2 *`
+	g, err := ParseGame(&Game{Raw: raw})
+	if err != nil {
+		t.Fatalf("Failed to parse game: %v", err)
+	}
+	mainline := g.Mainline()
+	if len(mainline) != 2 || mainline[0].SAN != "e8=Q" || mainline[1].SAN != "Kg2" {
+		t.Errorf("Unexpected moves %v", mainline)
+	}
+}
+
+func TestParseGameErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected error
+		pos      int
+	}{
+		{name: "Illegal move", input: "1. e4 e5 2. Ke3 *", expected: ErrIllegalMove(0), pos: 12},
+		{name: "Ambiguous move", input: `[FEN "4k3/8/8/8/8/8/8/R4RK1 w - - 0 1"] 1. Rd1 *`, expected: ErrAmbiguousMove(0), pos: 43},
+		{name: "Variation without move", input: "(1. d4) 1. e4 *", expected: ErrUnexpectedToken(0), pos: 0},
+		{name: "Stray character", input: "1. e4 / e5 *", expected: ErrUnexpectedCharacter(0), pos: 6},
+		{name: "Invalid square", input: "1. e4 ez5 2. d4 *", expected: ErrInvalidSquare(0), pos: 6},
+		{name: "Invalid FEN", input: `[FEN "8/8 w - -"] *`, expected: ErrInvalidFEN(0), pos: 0},
+		{name: "Unterminated comment", input: "1. e4 {oops", expected: ErrUnterminatedComment(0), pos: 7},
+		{name: "Several games", input: "[White \"A\"] 1. e4 1-0 [White \"B\"] 1. d4 0-1", expected: ErrMultipleGames(0), pos: 22},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGame(&Game{Raw: tt.input})
+			pgnErr, ok := err.(*PGNError)
+			if !ok || !pgnErr.Is(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, err)
+			}
+			if pgnErr.Pos() != tt.pos {
+				t.Errorf("Expected error at %d, got %d", tt.pos, pgnErr.Pos())
+			}
+		})
+	}
+}
+
+func TestWritePGNRoundTrip(t *testing.T) {
+	g := loadFixtureGame(t, "annotated_game.pgn")
+
+	var sb strings.Builder
+	if err := WritePGN(&sb, g); err != nil {
+		t.Fatalf("Failed to write game: %v", err)
+	}
+	for i, line := range strings.Split(sb.String(), "\n") {
+		if len(line) > exportLineWidth {
+			t.Errorf("Line %d is longer than %d characters: %q", i+1, exportLineWidth, line)
+		}
+	}
+
+	again, err := ParseGame(&Game{Raw: sb.String()})
+	if err != nil {
+		t.Fatalf("Failed to parse written game: %v\n%s", err, sb.String())
+	}
+	if again.String() != sb.String() {
+		t.Errorf("Writing is not stable\nfirst:\n%s\nsecond:\n%s", sb.String(), again.String())
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+)
+
+// ColoredSquare is a highlighted square from a %csl command (e.g. Rd4)
+type ColoredSquare struct {
+	Color  byte // R, G, Y or B
+	Square Square
+}
+
+func (c ColoredSquare) String() string {
+	return string(c.Color) + c.Square.String()
+}
+
+// Arrow is an arrow from a %cal command (e.g. Ge2e4)
+type Arrow struct {
+	Color byte // R, G, Y or B
+	From  Square
+	To    Square
+}
+
+func (a Arrow) String() string {
+	return string(a.Color) + a.From.String() + a.To.String()
+}
+
+// Helper to check the colour letter of a graphical annotation
+func isMarkColor(ch byte) bool {
+	return strings.IndexByte("RGYB", ch) >= 0
+}
+
+// decodeColoredSquares parses the parameters of [%csl Rd4,Ge5]
+func decodeColoredSquares(params []string) (any, error) {
+	squares := make([]ColoredSquare, 0, len(params))
+	for _, param := range params {
+		if len(param) != 3 || !isMarkColor(param[0]) || !isSquare(param[1:]) {
+			return nil, ErrInvalidCommandParam(0)
+		}
+		sq, _ := parseSquare(param[1:])
+		squares = append(squares, ColoredSquare{Color: param[0], Square: sq})
+	}
+	return squares, nil
+}
+
+// decodeArrows parses the parameters of [%cal Ge2e4,Rg1f3]
+func decodeArrows(params []string) (any, error) {
+	arrows := make([]Arrow, 0, len(params))
+	for _, param := range params {
+		if len(param) != 5 || !isMarkColor(param[0]) || !isSquare(param[1:3]) || !isSquare(param[3:]) {
+			return nil, ErrInvalidCommandParam(0)
+		}
+		from, _ := parseSquare(param[1:3])
+		to, _ := parseSquare(param[3:])
+		arrows = append(arrows, Arrow{Color: param[0], From: from, To: to})
+	}
+	return arrows, nil
+}
+
+// ColoredSquaresCommand encodes highlighted squares as a %csl command
+func ColoredSquaresCommand(squares []ColoredSquare) Command {
+	cmd := Command{Name: "csl"}
+	for _, sq := range squares {
+		cmd.Params = append(cmd.Params, sq.String())
+	}
+	return cmd
+}
+
+// ArrowsCommand encodes arrows as a %cal command
+func ArrowsCommand(arrows []Arrow) Command {
+	cmd := Command{Name: "cal"}
+	for _, arrow := range arrows {
+		cmd.Params = append(cmd.Params, arrow.String())
+	}
+	return cmd
+}
+
+// ColoredSquares returns the squares highlighted by the %csl command of a node
+func (n *MoveNode) ColoredSquares() ([]ColoredSquare, bool) {
+	return decodeNodeCommand[[]ColoredSquare](n, "csl")
+}
+
+// Arrows returns the arrows drawn by the %cal command of a node
+func (n *MoveNode) Arrows() ([]Arrow, bool) {
+	return decodeNodeCommand[[]Arrow](n, "cal")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGraphicalCommands(t *testing.T) {
+	g, err := ParseGame(&Game{Raw: "1. e4 {[%csl Rd4,Ge5][%cal Ge2e4,Rg1f3] Central control} *"})
+	if err != nil {
+		t.Fatalf("Failed to parse game: %v", err)
+	}
+	node := g.Mainline()[0]
+
+	squares, ok := node.ColoredSquares()
+	expectedSquares := []ColoredSquare{{Color: 'R', Square: newSquare(3, 3)}, {Color: 'G', Square: newSquare(4, 4)}}
+	if !ok || !reflect.DeepEqual(squares, expectedSquares) {
+		t.Errorf("Expected %v, got %v", expectedSquares, squares)
+	}
+
+	arrows, ok := node.Arrows()
+	expectedArrows := []Arrow{{Color: 'G', From: newSquare(4, 1), To: newSquare(4, 3)}, {Color: 'R', From: newSquare(6, 0), To: newSquare(5, 2)}}
+	if !ok || !reflect.DeepEqual(arrows, expectedArrows) {
+		t.Errorf("Expected %v, got %v", expectedArrows, arrows)
+	}
+
+	// Re-encode with an extra arrow and write the game back
+	node.SetCommand(ArrowsCommand(append(arrows, Arrow{Color: 'Y', From: newSquare(3, 0), To: newSquare(7, 4)})))
+	node.SetCommand(ColoredSquaresCommand(squares[:1]))
+	if got := g.String(); !strings.Contains(got, "{[%csl Rd4] [%cal Ge2e4,Rg1f3,Yd1h5] Central control}") {
+		t.Errorf("Unexpected PGN %q", got)
+	}
+}
+
+func TestGraphicalCommandErrors(t *testing.T) {
+	tests := []Command{
+		{Name: "csl", Params: []string{"Xd4"}},
+		{Name: "csl", Params: []string{"Ri9"}},
+		{Name: "csl", Params: []string{"Rd44"}},
+		{Name: "cal", Params: []string{"Ge2"}},
+		{Name: "cal", Params: []string{"Ge2z4"}},
+		{Name: "cal", Params: []string{"ge2e4"}},
+	}
+	for _, cmd := range tests {
+		if _, err := cmd.Decode(); !errors.Is(err, ErrInvalidCommandParam(0)) {
+			t.Errorf("Expected invalid parameter error for %v, got %v", cmd, err)
+		}
+	}
+}
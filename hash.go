@@ -0,0 +1,58 @@
+package main
+
+import "strings"
+
+// Random keys for Zobrist hashing, fixed so that hashes are stable across runs
+var (
+	zobristPieces    [12][64]uint64
+	zobristCastling  [16]uint64
+	zobristEnPassant [8]uint64 // By file
+	zobristBlack     uint64    // Black to move
+)
+
+// Order of the pieces in zobristPieces
+const zobristPieceLetters = "PNBRQKpnbrqk"
+
+func init() {
+	// splitmix64 from a fixed seed
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		return z ^ z>>31
+	}
+	for i := range zobristPieces {
+		for sq := range zobristPieces[i] {
+			zobristPieces[i][sq] = next()
+		}
+	}
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	zobristBlack = next()
+}
+
+// Hash returns a Zobrist hash of the position: pieces, side to move, castling
+// rights and en passant square (only when a capture there is possible), but
+// not the move counters, so that transpositions hash the same
+func (p *Position) Hash() uint64 {
+	var h uint64
+	for sq, piece := range p.board {
+		if piece != 0 {
+			h ^= zobristPieces[strings.IndexByte(zobristPieceLetters, piece)][sq]
+		}
+	}
+	h ^= zobristCastling[p.castling]
+	if ep := p.epSquare(); ep != NoSquare {
+		h ^= zobristEnPassant[ep.File()]
+	}
+	if p.turn == Black {
+		h ^= zobristBlack
+	}
+	return h
+}
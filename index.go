@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"slices"
+)
+
+// DefaultIndexTags are the tag values stored in a game index by default
+var DefaultIndexTags = []string{"Event", "Date", "White", "Black", "Result"}
+
+// IndexEntry locates a scanned game in its file
+type IndexEntry struct {
+	Offset int64
+	Length int
+	Line   int
+	Tags   []string // Values of the index tags, in GameIndex.Tags order
+}
+
+// GameIndex is a persistent index of the games of a PGN file: the position of
+// each game, numbered from 1 like the scanner returns them, and the values of
+// a few tags. It records the size, modification time and a checksum of the
+// file so that a stale index is detected.
+type GameIndex struct {
+	Source   string
+	Size     int64
+	ModTime  int64  // In Unix nanoseconds
+	Checksum uint64 // Of the size and the first and last checksumSample bytes
+	Tags     []string
+	Entries  []IndexEntry
+}
+
+// Version of the index encoding, changed whenever it changes
+const gameIndexVersion = 3
+
+// Bytes read from each end of the file for its checksum
+const checksumSample = 64 << 10
+
+// ErrStaleIndex is returned when a game index does not match its file
+var ErrStaleIndex = errors.New("index is out of date")
+
+// IndexPath returns where the index of a PGN file is stored
+func IndexPath(source string) string {
+	return source + ".idx"
+}
+
+// BuildIndex scans a PGN file once, recording every game and the values of
+// the given tags. UTF-16 files are rejected since offsets into the converted
+// text would not match the file.
+func BuildIndex(source string, tags []string) (*GameIndex, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := fileCompression(f)
+	if err != nil {
+		return nil, err
+	}
+	if c != Uncompressed {
+		return nil, fmt.Errorf("%s: cannot index %s data, decompress it first", source, c)
+	}
+	header := make([]byte, 2)
+	if n, _ := f.ReadAt(header, 0); n == 2 && (bytes.Equal(header, utf16LEBOM) || bytes.Equal(header, utf16BEBOM)) {
+		return nil, fmt.Errorf("%s: cannot index UTF-16 data, convert it to UTF-8 first", source)
+	}
+	x := &GameIndex{Source: source, Tags: tags}
+	if err := x.stamp(f); err != nil {
+		return nil, err
+	}
+
+	scanner := NewScanner(f)
+	for {
+		game, err := scanner.ScanGame()
+		if err == io.EOF {
+			return x, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := IndexEntry{Offset: game.Offset, Length: game.Size, Line: game.Line, Tags: make([]string, len(tags))}
+		if node := ParseSyntax(game.Raw).Game(); node != nil {
+			for i, key := range tags {
+				entry.Tags[i], _ = node.Tag(key)
+			}
+		}
+		x.Entries = append(x.Entries, entry)
+	}
+}
+
+// Helper to record the size, modification time and checksum of the file
+func (x *GameIndex) stamp(f *os.File) error {
+	s, err := readStamp(f)
+	x.Size, x.ModTime, x.Checksum = s.Size, s.ModTime, s.Checksum
+	return err
+}
+
+// fileStamp is the size, modification time and checksum of a file, which
+// tell whether an index built from it is out of date
+type fileStamp struct {
+	Size     int64
+	ModTime  int64 // In Unix nanoseconds
+	Checksum uint64
+}
+
+// Helper to read the stamp of an open file
+func readStamp(f *os.File) (fileStamp, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return fileStamp{}, err
+	}
+	s := fileStamp{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	s.Checksum, err = fileChecksum(f, s.Size)
+	return s, err
+}
+
+// Helper to read the stamp of a file by name
+func stampFile(path string) (fileStamp, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	defer f.Close()
+	return readStamp(f)
+}
+
+// fileChecksum hashes the size and both ends of a file, which is enough to
+// notice edits that keep the size and modification time without reading
+// a large file entirely
+func fileChecksum(f io.ReaderAt, size int64) (uint64, error) {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\n", size)
+	buf := make([]byte, min(size, checksumSample))
+	for _, offset := range []int64{0, size - int64(len(buf))} {
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return 0, err
+		}
+		h.Write(buf)
+	}
+	return h.Sum64(), nil
+}
+
+// Check returns ErrStaleIndex if the file changed since it was indexed
+func (x *GameIndex) Check() error {
+	s, err := stampFile(x.Source)
+	if err != nil {
+		return err
+	}
+	if s != (fileStamp{x.Size, x.ModTime, x.Checksum}) {
+		return ErrStaleIndex
+	}
+	return nil
+}
+
+// indexFile is the encoded form of a GameIndex
+type indexFile struct {
+	Version int
+	Index   *GameIndex
+}
+
+// Save writes the index to a file
+func (x *GameIndex) Save(path string) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(indexFile{Version: gameIndexVersion, Index: x}); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// LoadIndex reads an index saved with Save
+func LoadIndex(path string) (*GameIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var file indexFile
+	if err := gob.NewDecoder(f).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: invalid index: %w", path, err)
+	}
+	if file.Version != gameIndexVersion || file.Index == nil {
+		return nil, fmt.Errorf("%s: unsupported index version %d", path, file.Version)
+	}
+	return file.Index, nil
+}
+
+// OpenIndex returns the index of a PGN file, loading it from IndexPath when
+// it is up to date and has every requested tag, or else rebuilding and
+// saving it. The second result reports whether the index was rebuilt.
+func OpenIndex(source string, tags []string) (*GameIndex, bool, error) {
+	x, err := LoadIndex(IndexPath(source))
+	if err == nil && x.Source == source && x.Check() == nil && x.hasTags(tags) {
+		return x, false, nil
+	}
+
+	// Keep the tags of the previous index along with the new ones
+	tags = slices.Clone(tags)
+	if x != nil {
+		for _, key := range x.Tags {
+			if !slices.Contains(tags, key) {
+				tags = append(tags, key)
+			}
+		}
+	}
+	if x, err = BuildIndex(source, tags); err != nil {
+		return nil, false, err
+	}
+	return x, true, x.Save(IndexPath(source))
+}
+
+func (x *GameIndex) hasTags(tags []string) bool {
+	for _, key := range tags {
+		if !slices.Contains(x.Tags, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Games returns the number of games indexed
+func (x *GameIndex) Games() int {
+	return len(x.Entries)
+}
+
+// ReadGame reads game n, from 1, from the indexed file, converting it to UTF-8
+// like the scanner does by default
+func (x *GameIndex) ReadGame(r io.ReaderAt, n int) (*Game, error) {
+	if n < 1 || n > len(x.Entries) {
+		return nil, fmt.Errorf("no game %d, the file has %d", n, len(x.Entries))
+	}
+	entry := x.Entries[n-1]
+	buf := make([]byte, entry.Length)
+	if _, err := r.ReadAt(buf, entry.Offset); err != nil {
+		return nil, err
+	}
+	return &Game{Raw: DecodeText(buf, EncodingAuto), Offset: entry.Offset, Line: entry.Line, Size: entry.Length}, nil
+}
+
+// Find returns the numbers of the games whose indexed tag has a value
+func (x *GameIndex) Find(key, value string) ([]int, error) {
+	i := slices.Index(x.Tags, key)
+	if i < 0 {
+		return nil, fmt.Errorf("tag %s is not indexed", key)
+	}
+	var games []int
+	for n, entry := range x.Entries {
+		if entry.Tags[i] == value {
+			games = append(games, n+1)
+		}
+	}
+	return games, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const indexInput = `[Event "A"]
+[White "Carlsen"]
+
+1. e4 e5 1-0
+
+[Event "B"]
+[White "Caruana"]
+
+1. d4 d5 0-1
+
+[Event "C"]
+[White "Carlsen"]
+
+1. c4 *
+`
+
+func writeIndexInput(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(path, []byte(indexInput), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGameIndex(t *testing.T) {
+	path := writeIndexInput(t)
+	x, rebuilt, err := OpenIndex(path, []string{"White"})
+	if err != nil || !rebuilt {
+		t.Fatalf("Expected a new index, got %v %v", rebuilt, err)
+	}
+	if x.Games() != 3 {
+		t.Fatalf("Expected 3 games, got %d", x.Games())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	game, err := x.ReadGame(f, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Raw != "[Event \"B\"]\n[White \"Caruana\"]\n\n1. d4 d5 0-1" || game.Line != 6 {
+		t.Errorf("Unexpected game %+v", game)
+	}
+	if _, err := x.ReadGame(f, 4); err == nil {
+		t.Errorf("Expected an error for a missing game")
+	}
+
+	if games, err := x.Find("White", "Carlsen"); err != nil || !reflect.DeepEqual(games, []int{1, 3}) {
+		t.Errorf("Expected games 1 and 3, got %v %v", games, err)
+	}
+	if _, err := x.Find("Black", "Carlsen"); err == nil {
+		t.Errorf("Expected an error for a tag that is not indexed")
+	}
+
+	// The saved index is reused, and extended when a tag is missing
+	if _, rebuilt, err := OpenIndex(path, []string{"White"}); err != nil || rebuilt {
+		t.Errorf("Expected the saved index to be reused, got %v %v", rebuilt, err)
+	}
+	x, rebuilt, err = OpenIndex(path, []string{"Event"})
+	if err != nil || !rebuilt || !reflect.DeepEqual(x.Tags, []string{"Event", "White"}) {
+		t.Errorf("Expected a rebuild with both tags, got %v %v %v", x, rebuilt, err)
+	}
+}
+
+func TestGameIndexStale(t *testing.T) {
+	path := writeIndexInput(t)
+	x, err := BuildIndex(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Check(); err != nil {
+		t.Fatalf("Expected a fresh index, got %v", err)
+	}
+
+	// Same size and modification time, different content
+	info, _ := os.Stat(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(indexInput, "Carlsen", "Karlsen", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, time.Time{}, info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Check(); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("Expected a stale index after an edit, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(indexInput+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Check(); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("Expected a stale index after a size change, got %v", err)
+	}
+}
+
+func TestIndexCommand(t *testing.T) {
+	path := writeIndexInput(t)
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(""), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"index", path}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != path+": 3 games indexed\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run(e, []string{"index", "-find", "Event=C", path}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "[Event \"C\"]\n[White \"Carlsen\"]\n\n1. c4 *\n\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run(e, []string{"index", "-game", "1", path}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stdout.String(), "[Event \"A\"]") {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+}
+
+func TestGameIndexWithoutEvent(t *testing.T) {
+	// Without Event tags the scanner returns the games together
+	input := strings.ReplaceAll(indexInput, "[Event", "[Round")
+	path := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	x, err := BuildIndex(path, []string{"White"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Games() != 3 {
+		t.Fatalf("Expected 3 games, got %d", x.Games())
+	}
+	if games, err := x.Find("White", "Carlsen"); err != nil || !reflect.DeepEqual(games, []int{1, 3}) {
+		t.Errorf("Expected games 1 and 3, got %v %v", games, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	game, err := x.ReadGame(f, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[Round \"B\"]\n[White \"Caruana\"]\n\n1. d4 d5 0-1"
+	if game.Raw != want || game.Line != 6 || input[game.Offset:game.Offset+int64(game.Size)] != want {
+		t.Errorf("Unexpected game %+v", game)
+	}
+}
+
+func TestGameIndexUTF16(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(path, append([]byte{0xff, 0xfe}, "[\x00E\x00"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BuildIndex(path, nil); err == nil || !strings.Contains(err.Error(), "UTF-16") {
+		t.Errorf("Expected UTF-16 to be rejected, got %v", err)
+	}
+}
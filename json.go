@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONGame is the JSON representation of a game:
+//
+//	{
+//	  "tags": [{"name": "Event", "value": "Example"}, ...],
+//	  "comments": ["comment before the first move"],
+//	  "moves": [
+//	    {
+//	      "ply": 1, "san": "e4", "uci": "e2e4", "fen": "<position after the move>",
+//	      "comments": ["..."], "nags": [1],
+//	      "commands": [{"name": "clk", "params": ["0:05:00"]}],
+//	      "variations": [[{"ply": 1, "san": "d4", ...}, ...]]
+//	    },
+//	    ...
+//	  ],
+//	  "result": "1-0"
+//	}
+//
+// Tags keep their order. "moves" is the main line; the variations of a move
+// are alternatives to that move, each one a list of moves in the same format.
+// Optional fields are omitted when empty. When decoding, "san" is used if
+// present and "uci" otherwise; "ply" and "fen" are informative only.
+type JSONGame struct {
+	Tags     []JSONTag  `json:"tags"`
+	Comments []string   `json:"comments,omitempty"`
+	Moves    []JSONMove `json:"moves"`
+	Result   string     `json:"result"`
+}
+
+// JSONTag is a tag pair
+type JSONTag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// JSONMove is a move with its annotations and the variations replacing it
+type JSONMove struct {
+	Ply              int           `json:"ply"`
+	SAN              string        `json:"san"`
+	UCI              string        `json:"uci"`
+	FEN              string        `json:"fen"`
+	StartingComments []string      `json:"starting_comments,omitempty"`
+	Comments         []string      `json:"comments,omitempty"`
+	NAGs             []int         `json:"nags,omitempty"`
+	Commands         []JSONCommand `json:"commands,omitempty"`
+	Variations       [][]JSONMove  `json:"variations,omitempty"`
+}
+
+// JSONCommand is an embedded command such as [%clk 0:05:00]
+type JSONCommand struct {
+	Name   string   `json:"name"`
+	Params []string `json:"params,omitempty"`
+}
+
+// ToJSON converts a game to its JSON representation
+func ToJSON(g *ParsedGame) *JSONGame {
+	j := &JSONGame{Tags: []JSONTag{}, Comments: g.Root.Comments, Moves: []JSONMove{}, Result: g.Result}
+	for _, tag := range g.Tags {
+		j.Tags = append(j.Tags, JSONTag{Name: tag.Key, Value: tag.Value})
+	}
+	if next := g.Root.Next(); next != nil {
+		j.Moves = jsonLine(next)
+	}
+	return j
+}
+
+// Helper to convert a line starting at node
+func jsonLine(node *MoveNode) []JSONMove {
+	var moves []JSONMove
+	for ; node != nil; node = node.Next() {
+		move := JSONMove{
+			Ply:              node.Ply(),
+			SAN:              node.SAN,
+			UCI:              node.Move.UCI(),
+			FEN:              node.Position.FEN(),
+			StartingComments: node.StartingComments,
+			Comments:         node.Comments,
+			NAGs:             node.NAGs,
+		}
+		for _, cmd := range node.Commands {
+			move.Commands = append(move.Commands, JSONCommand{Name: cmd.Name, Params: cmd.Params})
+		}
+		if parent := node.Parent; parent.Children[0] == node {
+			for _, variation := range parent.Children[1:] {
+				move.Variations = append(move.Variations, jsonLine(variation))
+			}
+		}
+		moves = append(moves, move)
+	}
+	return moves
+}
+
+// FromJSON rebuilds a game from its JSON representation, replaying and
+// validating every move
+func FromJSON(j *JSONGame) (*ParsedGame, error) {
+	g := &ParsedGame{Result: j.Result}
+	for _, tag := range j.Tags {
+		g.Tags = append(g.Tags, Tag{Key: tag.Name, Value: tag.Value})
+	}
+	if g.Result == "" {
+		g.Result = "*"
+	}
+
+	start := NewPosition()
+	if fen, ok := g.Tag("FEN"); ok {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			return nil, err
+		}
+		start = p
+	}
+	g.Root = &MoveNode{Position: start, Comments: j.Comments}
+
+	if err := addJSONLine(g.Root, j.Moves); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Helper to add a line of moves after parent
+func addJSONLine(parent *MoveNode, moves []JSONMove) error {
+	for _, jm := range moves {
+		var m Move
+		var err error
+		if jm.SAN != "" {
+			m, err = parent.Position.ParseSAN(jm.SAN)
+		} else {
+			m, err = parent.Position.ParseUCI(jm.UCI)
+		}
+		if err != nil {
+			return err
+		}
+
+		node := parent.AddChild(m)
+		node.StartingComments = jm.StartingComments
+		node.Comments = jm.Comments
+		node.NAGs = jm.NAGs
+		for _, cmd := range jm.Commands {
+			node.Commands = append(node.Commands, Command{Name: cmd.Name, Params: cmd.Params})
+		}
+
+		for _, variation := range jm.Variations {
+			if err := addJSONLine(parent, variation); err != nil {
+				return err
+			}
+		}
+		parent = node
+	}
+	return nil
+}
+
+// EncodeJSON writes a game as a single line of JSON, suitable for NDJSON streams
+func EncodeJSON(w io.Writer, g *ParsedGame) error {
+	return json.NewEncoder(w).Encode(ToJSON(g))
+}
+
+// DecodeJSON reads a game written by EncodeJSON
+func DecodeJSON(r io.Reader) (*ParsedGame, error) {
+	var j JSONGame
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return nil, err
+	}
+	return FromJSON(&j)
+}
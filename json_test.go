@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := loadFixtureGame(t, "annotated_game.pgn")
+
+	var buf bytes.Buffer
+	if err := EncodeJSON(&buf, g); err != nil {
+		t.Fatalf("Failed to encode game: %v", err)
+	}
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Expected a single line of JSON")
+	}
+
+	decoded, err := DecodeJSON(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode game: %v", err)
+	}
+	if decoded.String() != g.String() {
+		t.Errorf("Round trip mismatch\nExpected:\n%s\ngot:\n%s", g.String(), decoded.String())
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	g, err := ParseGame(&Game{Raw: "[Event \"E\"]\n1. e4 {[%clk 0:05:00] fine} $1 (1. d4) 1-0"})
+	if err != nil {
+		t.Fatalf("Failed to parse game: %v", err)
+	}
+
+	data, err := json.Marshal(ToJSON(g))
+	if err != nil {
+		t.Fatalf("Failed to encode game: %v", err)
+	}
+
+	expected := `{"tags":[{"name":"Event","value":"E"}],"moves":[{"ply":1,"san":"e4","uci":"e2e4",` +
+		`"fen":"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1","comments":["fine"],"nags":[1],` +
+		`"commands":[{"name":"clk","params":["0:05:00"]}],"variations":[[{"ply":1,"san":"d4","uci":"d2d4",` +
+		`"fen":"rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq - 0 1"}]]}],"result":"1-0"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s\ngot      %s", expected, data)
+	}
+}
+
+func TestJSONDecodeUCIOnly(t *testing.T) {
+	g, err := DecodeJSON(strings.NewReader(`{"tags":[],"moves":[{"uci":"e2e4"},{"uci":"e7e5"}],"result":"*"}`))
+	if err != nil {
+		t.Fatalf("Failed to decode game: %v", err)
+	}
+	if mainline := g.Mainline(); len(mainline) != 2 || mainline[1].SAN != "e5" {
+		t.Errorf("Unexpected moves %v", mainline)
+	}
+
+	if _, err := DecodeJSON(strings.NewReader(`{"moves":[{"san":"e5"}]}`)); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+}
+
+func TestJSONCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader("[Event \"Good\"]\n1. e4 e5 1-0\n\n[Event \"Bad\"]\n1. e5 *\n"), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"json"}); err != errFailed {
+		t.Errorf("Expected the bad game to fail the command, got %v", err)
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 1 {
+		t.Errorf("Expected 1 NDJSON line, got %d", lines)
+	}
+	if !strings.Contains(stderr.String(), "<stdin>:5:4: error: illegal move\n 5 | 1. e5 *\n") {
+		t.Errorf("Expected the illegal move to be reported, got %q", stderr.String())
+	}
+
+	e = &env{stdin: strings.NewReader(stdout.String()), stdout: &bytes.Buffer{}, stderr: &stderr}
+	if err := run(e, []string{"json", "-r"}); err != nil {
+		t.Fatalf("Failed to convert back: %v", err)
+	}
+	if got := e.stdout.(*bytes.Buffer).String(); got != "[Event \"Good\"]\n\n1. e4 e5 1-0\n\n" {
+		t.Errorf("Unexpected PGN %q", got)
+	}
+	// The scanner keeps games without an Event tag together, each is converted
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader("[White \"A\"]\n\n1. e4 e5 1-0\n\n[White \"B\"]\n\n1. d4 d5 0-1\n"), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"json"}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 NDJSON lines, got %q", stdout.String())
+	}
+
+	// A comment after the result belongs to the game
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader("[Event \"A\"]\n\n1. e4 e5 1-0 {trailing comment}\n"), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"json"}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 1 || !strings.Contains(stdout.String(), `"comments":["trailing comment"]}],"result":"1-0"`) {
+		t.Errorf("Expected the comment on the last move, got %q", stdout.String())
+	}
+	e = &env{stdin: strings.NewReader(stdout.String()), stdout: &bytes.Buffer{}, stderr: &stderr}
+	if err := run(e, []string{"json", "-r"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.stdout.(*bytes.Buffer).String(); got != "[Event \"A\"]\n\n1. e4 e5 {trailing comment} 1-0\n\n" {
+		t.Errorf("Unexpected PGN %q", got)
+	}
+}
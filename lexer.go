@@ -21,12 +21,12 @@ const (
 	COMMENT_START              // {
 	COMMENT_END                // }
 	COMMENT                    // The comment text
-	RESULT                     // 1-0, 0-1, 1/2-1/2
+	RESULT                     // 1-0, 0-1, 1/2-1/2, *
 	CAPTURE                    // 'x' in moves
 	FILE                       // a-h in moves when used as disambiguation
 	RANK                       // 1-8 in moves when used as disambiguation
-	KINGSIDE_CASTLE            // 0-0
-	QUEENSIDE_CASTLE           // 0-0-0
+	KINGSIDE_CASTLE            // O-O or 0-0
+	QUEENSIDE_CASTLE           // O-O-O or 0-0-0
 	PROMOTION                  // = in moves
 	PROMOTION_PIECE            // The piece being promoted to (Q, R, B, N)
 	CHECK                      // + in moves
@@ -38,12 +38,16 @@ const (
 	COMMAND_NAME               // The command name (e.g., clk, eval)
 	COMMAND_PARAM              // Command parameter
 	COMMAND_END                // ]
+	ANNOTATION                 // !, ?, !!, ??, !? or ?! after a move
+	ILLEGAL                    // Text the lexer cannot make sense of, skipped up to the next token
 )
 
 type Token struct {
 	Error error
 	Value string
 	Type  TokenType
+	Pos   int // Byte offset of the first character of the token in the input
+	End   int // Byte offset just past the last character of the token
 }
 
 type Lexer struct {
@@ -160,11 +164,29 @@ func (l *Lexer) readResult() Token {
 	return Token{Type: MOVE_NUMBER, Value: result}
 }
 
+// readAnnotation reads a move suffix annotation: !, ?, !!, ??, !? or ?!
+func (l *Lexer) readAnnotation() Token {
+	position := l.position
+	for (l.ch == '!' || l.ch == '?') && l.position-position < 2 {
+		l.readChar()
+	}
+	return Token{Type: ANNOTATION, Value: l.input[position:l.position]}
+}
+
+// readLiteral consumes s if the input continues with it
+func (l *Lexer) readLiteral(s string) bool {
+	if !strings.HasPrefix(l.input[l.position:], s) {
+		return false
+	}
+	l.readPosition = l.position + len(s)
+	l.readChar()
+	return true
+}
+
 func (l *Lexer) readRank() Token {
 	rank := string(l.ch)
 	if !isRank(l.ch) {
-		l.readChar()
-		return Token{Type: RANK, Error: ErrInvalidRank(l.position), Value: rank}
+		return l.readIllegal(l.position, ErrInvalidRank)
 	}
 	l.readChar()
 	return Token{Type: RANK, Value: rank}
@@ -218,8 +240,7 @@ func (l *Lexer) readPieceMove() Token {
 	// Capture just the piece
 	piece := string(l.ch)
 	if !isPiece(l.ch) {
-		l.readChar()
-		return Token{Type: PIECE, Error: ErrInvalidPiece(l.position), Value: piece}
+		return l.readIllegal(l.position, ErrInvalidPiece)
 	}
 	l.readChar()
 
@@ -268,8 +289,7 @@ func (l *Lexer) readMove() Token {
 
 	// Validate the square (e.g., "e4")
 	if length < 2 || !isFile(l.input[position]) || position+1 >= len(l.input) || !isDigit(l.input[position+1]) {
-		l.readChar()
-		return Token{Type: SQUARE, Value: "", Error: ErrInvalidSquare(position)}
+		return l.readIllegal(position, ErrInvalidSquare)
 	}
 
 	return Token{Type: SQUARE, Value: l.input[position:l.position]}
@@ -278,13 +298,36 @@ func (l *Lexer) readMove() Token {
 func (l *Lexer) readPromotionPiece() Token {
 	piece := string(l.ch)
 	if !isPiece(l.ch) {
-		l.readChar()
-		return Token{Type: PROMOTION_PIECE, Error: ErrInvalidPiece(l.position), Value: piece}
+		return l.readIllegal(l.position, ErrInvalidPiece)
 	}
 	l.readChar()
 	return Token{Type: PROMOTION_PIECE, Value: piece}
 }
 
+// readIllegal reads text the lexer cannot make sense of, from position up to
+// the next plausible token boundary: whitespace, a brace, a parenthesis, a
+// bracket, a NAG or a move number. Lexing resumes there, so that one typo
+// does not hide the rest of the game.
+func (l *Lexer) readIllegal(position int, err func(pos int) error) Token {
+	if l.position == position {
+		l.readChar()
+	}
+	for l.ch != 0 && !isTokenBoundary(l.ch) && !l.atMoveNumber() {
+		l.readChar()
+	}
+	value := l.input[position:l.position]
+	return Token{Type: ILLEGAL, Value: value, Error: withHint(err(position), syntaxHint(value))}
+}
+
+// Helper to tell whether the input continues with a move number such as 12.
+func (l *Lexer) atMoveNumber() bool {
+	i := l.position
+	for i < len(l.input) && isDigit(l.input[i]) {
+		i++
+	}
+	return i > l.position && i < len(l.input) && l.input[i] == '.'
+}
+
 func (l *Lexer) readChar() {
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
@@ -295,13 +338,24 @@ func (l *Lexer) readChar() {
 	l.readPosition += 1
 }
 
+// readTagValue reads a quoted tag value, unescaping \" and \\ as the PGN
+// standard allows so that values written by SyntaxNode.SetTag read back the
+// same
 func (l *Lexer) readTagValue() Token {
 	l.readChar() // skip opening quote
 	position := l.position
+	escaped := false
 	for l.ch != '"' && l.ch != 0 {
+		if l.ch == '\\' && (l.peekChar() == '"' || l.peekChar() == '\\') {
+			escaped = true
+			l.readChar() // skip the backslash, keep the escaped character
+		}
 		l.readChar()
 	}
 	value := l.input[position:l.position]
+	if escaped {
+		value = tagValueUnescaper.Replace(value)
+	}
 	l.readChar() // skip closing quote
 	return Token{Type: TAG_VALUE, Value: value}
 }
@@ -353,8 +407,26 @@ func (l *Lexer) readCastling() (Token, bool) {
 	return Token{Type: KINGSIDE_CASTLE, Value: "O-O"}, true
 }
 
+// NextToken returns the next token along with the span of input it was read from.
+// Everything between the End of one token and the Pos of the next is trivia
+// (whitespace) that the lexer skipped.
 func (l *Lexer) NextToken() Token {
 	l.skipWhitespace()
+	start := l.offset(l.position)
+	tok := l.nextToken()
+	tok.Pos = start
+	tok.End = max(start, l.offset(l.position))
+	return tok
+}
+
+// offset clamps a lexer position to the bounds of the input, since readChar
+// may step past the end when it hits EOF.
+func (l *Lexer) offset(pos int) int {
+	return min(pos, len(l.input))
+}
+
+func (l *Lexer) nextToken() Token {
+	l.skipWhitespace()
 
 	if l.inCommand {
 		switch l.ch {
@@ -418,13 +490,25 @@ func (l *Lexer) NextToken() Token {
 		l.readChar()
 		return Token{Type: DOT, Value: "."}
 	case 'x':
+		if l.inTag {
+			return l.readTagKey()
+		}
 		l.readChar()
 		return Token{Type: CAPTURE, Value: "x"}
 	case '-':
 		return l.readResult()
 	case '$':
 		return l.readNAG()
+	case '*':
+		l.readChar()
+		return Token{Type: RESULT, Value: "*"}
+	case '!', '?':
+		return l.readAnnotation()
 	case 'O':
+		// Tag keys such as Opening are not castling
+		if l.inTag {
+			return l.readTagKey()
+		}
 		// Check for castling
 		if token, isCastling := l.readCastling(); isCastling {
 			return token
@@ -445,6 +529,17 @@ func (l *Lexer) NextToken() Token {
 			return l.readTagValue()
 		}
 
+		// Draws and castling spelled with zeros
+		if l.readLiteral("1/2-1/2") {
+			return Token{Type: RESULT, Value: "1/2-1/2"}
+		}
+		if l.readLiteral("0-0-0") {
+			return Token{Type: QUEENSIDE_CASTLE, Value: "O-O-O"}
+		}
+		if l.readLiteral("0-0") {
+			return Token{Type: KINGSIDE_CASTLE, Value: "O-O"}
+		}
+
 		// Look at previous characters to determine context
 		if l.position > 0 && unicode.IsUpper(rune(l.input[l.position-1])) {
 			// If preceded by a piece, it's a rank disambiguation
@@ -487,7 +582,5 @@ func (l *Lexer) NextToken() Token {
 		}
 	}
 
-	tok := Token{Type: EOF, Value: string(l.ch)}
-	l.readChar()
-	return tok
+	return l.readIllegal(l.position, ErrUnexpectedCharacter)
 }
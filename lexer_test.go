@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -86,6 +87,26 @@ func TestLexer(t *testing.T) {
 	}
 }
 
+func TestTagValueEscapes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`[Annotator "The \"Pro\""]`, `The "Pro"`},
+		{`[Site "C:\\games"]`, `C:\games`},
+		{`[Site "a\b"]`, `a\b`}, // Other backslashes are kept
+	}
+	for _, tt := range tests {
+		lexer := NewLexer(tt.input)
+		lexer.NextToken()
+		lexer.NextToken()
+		tok := lexer.NextToken()
+		if tok.Type != TAG_VALUE || tok.Value != tt.want || tok.End != len(tt.input)-1 {
+			t.Errorf("%s: expected the value %q, got %+v", tt.input, tt.want, tok)
+		}
+	}
+}
+
 func TestCheck(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -718,6 +739,16 @@ func TestCaslting(t *testing.T) {
 				{Type: QUEENSIDE_CASTLE, Value: "O-O-O"},
 			},
 		},
+		{
+			name:  "Tag key starting with O",
+			input: `[Opening "Italian Game"]`,
+			expected: []Token{
+				{Type: TAG_START, Value: "["},
+				{Type: TAG_KEY, Value: "Opening"},
+				{Type: TAG_VALUE, Value: "Italian Game"},
+				{Type: TAG_END, Value: "]"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -740,6 +771,74 @@ func TestCaslting(t *testing.T) {
 	}
 }
 
+func TestResultsAndAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Token
+	}{
+		{
+			name:  "Draw",
+			input: "e4 1/2-1/2",
+			expected: []Token{
+				{Type: SQUARE, Value: "e4"},
+				{Type: RESULT, Value: "1/2-1/2"},
+			},
+		},
+		{
+			name:  "Unfinished game",
+			input: "e4 *",
+			expected: []Token{
+				{Type: SQUARE, Value: "e4"},
+				{Type: RESULT, Value: "*"},
+			},
+		},
+		{
+			name:  "Castling with zeros",
+			input: "0-0 0-0-0+ 0-1",
+			expected: []Token{
+				{Type: KINGSIDE_CASTLE, Value: "O-O"},
+				{Type: QUEENSIDE_CASTLE, Value: "O-O-O"},
+				{Type: CHECK, Value: "+"},
+				{Type: RESULT, Value: "0-1"},
+			},
+		},
+		{
+			name:  "Move annotations",
+			input: "e4! e5?! Nf3!!",
+			expected: []Token{
+				{Type: SQUARE, Value: "e4"},
+				{Type: ANNOTATION, Value: "!"},
+				{Type: SQUARE, Value: "e5"},
+				{Type: ANNOTATION, Value: "?!"},
+				{Type: PIECE, Value: "N"},
+				{Type: SQUARE, Value: "f3"},
+				{Type: ANNOTATION, Value: "!!"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+
+			for i, expected := range tt.expected {
+				token := lexer.NextToken()
+				if token.Type != expected.Type || token.Value != expected.Value {
+					t.Errorf("Token %d - Expected {%v, %q}, got {%v, %q}",
+						i, expected.Type, expected.Value, token.Type, token.Value)
+				}
+			}
+
+			// Verify we get EOF after all tokens
+			token := lexer.NextToken()
+			if token.Type != EOF {
+				t.Errorf("Expected EOF token, got %v", token.Type)
+			}
+		})
+	}
+}
+
 func TestFuzzRepro_b41648629adb0a5d_y(t *testing.T) {
 	input := "y"
 	lexer := NewLexer(input)
@@ -948,3 +1047,43 @@ func validateTokens(t *testing.T, tokens []Token) {
 	}
 
 }
+
+func TestIllegalRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Type ILLEGAL is written !value
+	}{
+		{"stray character", "1. e4 / e5", []string{"1", ".", "e4", "!/", "e5"}},
+		{"invalid square", "1. e4 ez5 2. d4", []string{"1", ".", "e4", "!ez5", "2", ".", "d4"}},
+		{"invalid piece", "1. Zf3 {ok} Nc6", []string{"1", ".", "!Zf3", "{", "ok", "}", "N", "c6"}},
+		{"up to a move number", "1. e4 @@2. d4", []string{"1", ".", "e4", "!@@", "2", ".", "d4"}},
+		{"up to a variation", "1. e4 (e5&x) d5", []string{"1", ".", "e4", "(", "e5", "!&x", ")", "d5"}},
+		{"non-ascii", "1. e4 ½-½", []string{"1", ".", "e4", "!½-½"}},
+		{"invalid promotion", "e8=Z+ e5", []string{"e8", "=", "!Z+", "e5"}},
+		{"invalid rank", "1. N9f3 e5", []string{"1", ".", "N", "!9f3", "e5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			var got []string
+			for {
+				tok := lexer.NextToken()
+				if tok.Type == EOF {
+					break
+				}
+				if tok.Type == ILLEGAL {
+					if tok.Error == nil || tok.Value != tt.input[tok.Pos:tok.End] {
+						t.Errorf("Unexpected illegal token %+v", tok)
+					}
+					got = append(got, "!"+tok.Value)
+					continue
+				}
+				got = append(got, tok.Value)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
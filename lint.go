@@ -0,0 +1,525 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity is how serious a lint diagnostic is
+type Severity int
+
+const (
+	SeverityError   Severity = iota // The game cannot be imported as is
+	SeverityWarning                 // The game breaks the PGN standard but can be read
+	SeverityInfo                    // The game is valid but not in export format
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// MarshalText encodes the severity by name in JSON output
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic is a problem found by a lint rule
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	Game     int      `json:"game,omitempty"`   // Number of the game in the file, starting at 1
+	Offset   int64    `json:"offset"`           // Byte offset in the file
+	Line     int      `json:"line"`             // Starting at 1
+	Column   int      `json:"column"`           // Byte column, starting at 1
+	Length   int      `json:"length,omitempty"` // Bytes of source the problem spans
+	Source   string   `json:"source,omitempty"` // The line of the game containing the problem
+	Hint     string   `json:"hint,omitempty"`   // Suggested fix, such as "did you mean O-O?"
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+	if d.Rule != "" {
+		s += " [" + d.Rule + "]"
+	}
+	return s
+}
+
+// LintRule is a check with a stable ID that can be enabled or disabled
+type LintRule struct {
+	ID       string
+	Severity Severity
+	Doc      string
+	check    func(c *lintContext)
+}
+
+// lintRules lists every rule in the order diagnostics are reported. IDs are
+// part of the command line interface and must not change.
+var lintRules = []*LintRule{
+	{ID: "syntax-error", Severity: SeverityError, Doc: "text the lexer cannot read", check: checkSyntax},
+	{ID: "unbalanced-braces", Severity: SeverityError, Doc: "comment without its closing } or stray }", check: checkBraces},
+	{ID: "unbalanced-parens", Severity: SeverityError, Doc: "variation without its closing ) or stray )", check: checkParens},
+	{ID: "missing-result", Severity: SeverityError, Doc: "movetext does not end with a result", check: checkMissingResult},
+	{ID: "result-mismatch", Severity: SeverityError, Doc: "Result tag differs from the movetext result", check: checkResultMismatch},
+	{ID: "illegal-move", Severity: SeverityError, Doc: "move that is illegal or ambiguous in its position", check: checkIllegalMove},
+	{ID: "missing-tag", Severity: SeverityWarning, Doc: "tag of the Seven Tag Roster is missing", check: checkMissingTags},
+	{ID: "duplicate-tag", Severity: SeverityWarning, Doc: "tag appears more than once", check: checkDuplicateTags},
+	{ID: "invalid-tag-value", Severity: SeverityWarning, Doc: "tag value does not follow its standard format", check: checkTagValues},
+	{ID: "tag-order", Severity: SeverityInfo, Doc: "Seven Tag Roster tags are not first and in order", check: checkTagOrder},
+	{ID: "non-standard-move", Severity: SeverityInfo, Doc: "move is not written in standard SAN", check: checkMoveText},
+}
+
+// LintRules returns every available rule
+func LintRules() []*LintRule {
+	return lintRules
+}
+
+// sevenTagRoster lists the tags every game must have, in export order
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Linter runs the enabled rules over games. All rules are enabled by default.
+type Linter struct {
+	disabled map[string]bool
+}
+
+// NewLinter creates a linter with every rule enabled
+func NewLinter() *Linter {
+	return &Linter{disabled: map[string]bool{}}
+}
+
+// Enable turns rules back on
+func (l *Linter) Enable(ids ...string) error {
+	for _, id := range ids {
+		if findLintRule(id) == nil {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		delete(l.disabled, id)
+	}
+	return nil
+}
+
+// Disable turns rules off
+func (l *Linter) Disable(ids ...string) error {
+	for _, id := range ids {
+		if findLintRule(id) == nil {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		l.disabled[id] = true
+	}
+	return nil
+}
+
+// Only enables the given rules and disables every other one
+func (l *Linter) Only(ids ...string) error {
+	for _, rule := range lintRules {
+		l.disabled[rule.ID] = true
+	}
+	return l.Enable(ids...)
+}
+
+func findLintRule(id string) *LintRule {
+	for _, rule := range lintRules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Lint checks a scanned game. Diagnostics are positioned using the game's
+// Offset and Line and sorted by position; File and Game are left for the
+// caller to fill in.
+func (l *Linter) Lint(game *Game) []Diagnostic {
+	c := &lintContext{game: game, file: ParseSyntax(game.Raw)}
+	games := c.file.Games()
+	if len(games) == 0 {
+		// Nothing but trivia, or text the lexer gave up on
+		games = []*SyntaxNode{nil}
+	}
+
+	for i, node := range games {
+		c.node = node
+		c.last = i == len(games)-1
+		c.parsed, c.moves, c.err = nil, nil, nil
+		for _, rule := range lintRules {
+			if !l.disabled[rule.ID] {
+				c.rule = rule
+				rule.check(c)
+			}
+		}
+	}
+	slices.SortStableFunc(c.diags, func(a, b Diagnostic) int { return cmp.Compare(a.Offset, b.Offset) })
+	return c.diags
+}
+
+// lintContext is the state shared by the rules checking one game node
+type lintContext struct {
+	game *Game
+	file *SyntaxNode
+	node *SyntaxNode // Game being checked, nil if the input has none
+	last bool        // Whether node is the last game, which owns the EOF leaf
+	rule *LintRule
+
+	parsed *ParsedGame
+	moves  map[*SyntaxNode]*MoveNode
+	err    error
+
+	diags []Diagnostic
+}
+
+// report adds a diagnostic of the current rule at a byte offset of the game
+func (c *lintContext) report(pos int, format string, args ...any) {
+	c.reportHint(pos, "", format, args...)
+}
+
+// reportError adds a diagnostic for an error, with its hint
+func (c *lintContext) reportError(err error, fallback int) {
+	c.reportHint(errorPos(err, fallback), errorHint(err), "%v", err)
+}
+
+// reportHint adds a diagnostic with a suggested fix
+func (c *lintContext) reportHint(pos int, hint, format string, args ...any) {
+	d := newDiagnostic(c.game, pos, fmt.Sprintf(format, args...))
+	d.Rule, d.Severity, d.Hint = c.rule.ID, c.rule.Severity, hint
+	c.diags = append(c.diags, d)
+}
+
+// replay builds the move tree of the game once, for the rules that need it
+func (c *lintContext) replay() (*ParsedGame, error) {
+	if c.parsed == nil && c.err == nil && c.node != nil {
+		c.moves = map[*SyntaxNode]*MoveNode{}
+		c.parsed, c.err = parseGameSyntax(c.node, c.moves)
+	}
+	return c.parsed, c.err
+}
+
+// eof returns the final leaf of the input if the current game owns it
+func (c *lintContext) eof() *SyntaxNode {
+	if !c.last {
+		return nil
+	}
+	return c.file.Children[len(c.file.Children)-1]
+}
+
+// Helper to visit every leaf of the current game
+func (c *lintContext) leaves(fn func(parent, leaf *SyntaxNode)) {
+	if c.node == nil {
+		return
+	}
+	var walk func(parent *SyntaxNode)
+	walk = func(parent *SyntaxNode) {
+		for _, child := range parent.Children {
+			if child.Kind == SyntaxToken {
+				fn(parent, child)
+			} else {
+				walk(child)
+			}
+		}
+	}
+	walk(c.node)
+}
+
+// Helper to locate an error, falling back to a default position
+func errorPos(err error, fallback int) int {
+	var pgnErr *PGNError
+	if errors.As(err, &pgnErr) {
+		return pgnErr.Pos()
+	}
+	return fallback
+}
+
+func (c *lintContext) startPos() int {
+	if c.node == nil {
+		return 0
+	}
+	return c.node.firstLeaf().Token.Pos
+}
+
+func checkSyntax(c *lintContext) {
+	found := false
+	c.leaves(func(parent, leaf *SyntaxNode) {
+		switch {
+		case leaf.Token.Type == ILLEGAL:
+			c.reportHint(leaf.Token.Pos, errorHint(leaf.Token.Error), "%v %q", leaf.Token.Error, leaf.Raw)
+			found = true
+		case leaf.Token.Error != nil:
+			c.reportError(leaf.Token.Error, leaf.Token.Pos)
+			found = true
+		}
+	})
+
+	if eof := c.eof(); eof != nil && eof.Raw != "" {
+		if eof.Token.Error == nil {
+			c.report(len(c.game.Raw)-len(eof.Raw), "unreadable text %q", firstLine(eof.Raw))
+			return
+		} else if !errors.Is(eof.Token.Error, ErrUnterminatedComment(0)) {
+			c.reportError(eof.Token.Error, eof.Token.Pos)
+			return
+		}
+	}
+
+	// Misplaced tokens the lexer accepted, such as a NAG before any move.
+	// Unbalanced delimiters also confuse the replay but have their own rules.
+	if _, err := c.replay(); err != nil && !found && c.delimiters().ok() && errors.Is(err, ErrUnexpectedToken(0)) {
+		c.reportError(err, c.startPos())
+	}
+}
+
+// delimiterErrors holds the positions of unbalanced braces and parentheses
+type delimiterErrors struct {
+	strayBraces []int // } outside a comment
+	strayParens []int // ) outside a variation
+	unclosed    []int // ( without its )
+	openComment error // Unterminated comment that swallowed the end of the input
+}
+
+// Helper to find the unbalanced braces and parentheses of the game
+func (c *lintContext) delimiters() delimiterErrors {
+	var d delimiterErrors
+	if eof := c.eof(); eof != nil && errors.Is(eof.Token.Error, ErrUnterminatedComment(0)) {
+		d.openComment = eof.Token.Error
+	}
+	if c.node == nil {
+		return d
+	}
+	c.node.Walk(func(n *SyntaxNode) bool {
+		if n.Kind == SyntaxVariation {
+			if last := n.Children[len(n.Children)-1]; last.Kind != SyntaxToken || last.Token.Type != VARIATION_END {
+				d.unclosed = append(d.unclosed, n.firstLeaf().Token.Pos)
+			}
+		}
+		for _, child := range n.Children {
+			if child.Kind != SyntaxToken {
+				continue
+			}
+			// The builder only puts closing tokens in their own kind of node when they match
+			switch {
+			case child.Token.Type == COMMENT_END && n.Kind != SyntaxComment:
+				d.strayBraces = append(d.strayBraces, child.Token.Pos)
+			case child.Token.Type == VARIATION_END && n.Kind != SyntaxVariation:
+				d.strayParens = append(d.strayParens, child.Token.Pos)
+			}
+		}
+		return true
+	})
+	return d
+}
+
+func (d delimiterErrors) ok() bool {
+	return d.openComment == nil && len(d.strayBraces)+len(d.strayParens)+len(d.unclosed) == 0
+}
+
+func checkBraces(c *lintContext) {
+	d := c.delimiters()
+	for _, pos := range d.strayBraces {
+		c.report(pos, "} without a matching {")
+	}
+	if d.openComment != nil {
+		c.reportHint(errorPos(d.openComment, 0), "close the comment with }", "{ without a matching }")
+	}
+}
+
+func checkParens(c *lintContext) {
+	d := c.delimiters()
+	for _, pos := range d.unclosed {
+		c.reportHint(pos, "close the variation with )", "( without a matching )")
+	}
+	for _, pos := range d.strayParens {
+		c.report(pos, ") without a matching (")
+	}
+}
+
+// Helper to find the result token that terminates the game, if any
+func gameResult(node *SyntaxNode) *SyntaxNode {
+	if node == nil {
+		return nil
+	}
+	// Comments may follow the result
+	for i := len(node.Children) - 1; i >= 0; i-- {
+		last := node.Children[i]
+		if last.Kind != SyntaxComment {
+			if last.Kind == SyntaxToken && last.Token.Type == RESULT {
+				return last
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func checkMissingResult(c *lintContext) {
+	if c.node != nil && gameResult(c.node) == nil {
+		end := c.node.Children[len(c.node.Children)-1]
+		c.report(len(strings.TrimRight(c.game.Raw[:endPos(end)], " \t\r\n")), "game does not end with a result")
+	}
+}
+
+// Helper to find the offset just past the last leaf of a node
+func endPos(n *SyntaxNode) int {
+	for n.Kind != SyntaxToken {
+		if len(n.Children) == 0 {
+			return 0
+		}
+		n = n.Children[len(n.Children)-1]
+	}
+	return n.Token.Pos + len(n.Raw)
+}
+
+func checkResultMismatch(c *lintContext) {
+	result := gameResult(c.node)
+	if result == nil {
+		return
+	}
+	if value, ok := c.node.Tag("Result"); ok && value != result.Token.Value {
+		c.report(result.Token.Pos, "result %s differs from Result tag %q", result.Token.Value, value)
+	}
+}
+
+func checkIllegalMove(c *lintContext) {
+	_, err := c.replay()
+	if errors.Is(err, ErrIllegalMove(0)) || errors.Is(err, ErrAmbiguousMove(0)) {
+		c.reportError(err, c.startPos())
+	}
+}
+
+func checkMissingTags(c *lintContext) {
+	if c.node == nil {
+		return
+	}
+	var missing []string
+	for _, key := range sevenTagRoster {
+		if c.node.findTag(key) == nil {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		c.report(c.startPos(), "missing tags: %s", strings.Join(missing, ", "))
+	}
+}
+
+func checkDuplicateTags(c *lintContext) {
+	if c.node == nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, child := range c.node.Children {
+		if child.Kind != SyntaxTag {
+			continue
+		}
+		key := child.tagKey()
+		if seen[key] {
+			c.report(child.firstLeaf().Token.Pos, "duplicate tag %s", key)
+		}
+		seen[key] = true
+	}
+}
+
+// tagValidators check the tags whose values have a standard format
+var tagValidators = map[string]func(string) error{
+	"Date":        func(s string) error { _, err := ParseDate(s); return err },
+	"EventDate":   func(s string) error { _, err := ParseDate(s); return err },
+	"UTCDate":     func(s string) error { _, err := ParseDate(s); return err },
+	"Round":       func(s string) error { _, err := ParseRound(s); return err },
+	"TimeControl": func(s string) error { _, err := ParseTimeControl(s); return err },
+	"WhiteElo":    func(s string) error { _, err := ParseElo(s); return err },
+	"BlackElo":    func(s string) error { _, err := ParseElo(s); return err },
+	"ECO":         func(s string) error { _, err := ParseECO(s); return err },
+	"FEN":         func(s string) error { _, err := ParseFEN(s); return err },
+	"Result": func(s string) error {
+		if !isResult(s) {
+			return ErrInvalidTagValue(0)
+		}
+		return nil
+	},
+}
+
+func checkTagValues(c *lintContext) {
+	if c.node == nil {
+		return
+	}
+	for _, child := range c.node.Children {
+		if child.Kind != SyntaxTag {
+			continue
+		}
+		validate, ok := tagValidators[child.tagKey()]
+		value := child.leaf(TAG_VALUE)
+		if !ok || value == nil {
+			continue
+		}
+		if err := validate(value.Token.Value); err != nil {
+			c.report(value.Token.Pos, "invalid %s tag value %q", child.tagKey(), value.Token.Value)
+		}
+	}
+}
+
+func checkTagOrder(c *lintContext) {
+	if c.node == nil {
+		return
+	}
+	seen := map[string]bool{}
+	next := 0 // Index in the roster of the next tag expected
+	for _, child := range c.node.Children {
+		if child.Kind != SyntaxTag {
+			continue
+		}
+		key := child.tagKey()
+		if seen[key] {
+			continue // Reported by duplicate-tag
+		}
+		seen[key] = true
+
+		// Skip roster tags that are missing altogether
+		for next < len(sevenTagRoster) && c.node.findTag(sevenTagRoster[next]) == nil {
+			next++
+		}
+		if next == len(sevenTagRoster) {
+			return
+		}
+		if key != sevenTagRoster[next] {
+			c.report(child.firstLeaf().Token.Pos, "tag %s is out of order, expected %s", key, sevenTagRoster[next])
+			return
+		}
+		next++
+	}
+}
+
+func checkMoveText(c *lintContext) {
+	if c.node == nil {
+		return
+	}
+	if _, err := c.replay(); err != nil && len(c.moves) == 0 {
+		return
+	}
+	// Moves replayed before any error are still checked
+	c.node.Walk(func(n *SyntaxNode) bool {
+		if n.Kind != SyntaxMove {
+			return true
+		}
+		move, ok := c.moves[n]
+		if !ok {
+			return false
+		}
+		text := strings.TrimSpace(n.String())
+		if text != move.SAN {
+			c.report(n.firstLeaf().Token.Pos, "non-standard move %q, expected %s", text, move.SAN)
+		}
+		return false
+	})
+}
+
+// Helper to cut a text to its first line for messages
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	return strings.TrimSpace(s)
+}
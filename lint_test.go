@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lintRoster = "[Event \"E\"]\n[Site \"S\"]\n[Date \"2023.12.06\"]\n[Round \"1\"]\n[White \"W\"]\n[Black \"B\"]\n[Result \"1-0\"]\n\n"
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rules []string
+	}{
+		{"clean", lintRoster + "1. e4 e5 2. Nf3 Nc6 1-0", nil},
+		{"missing tags", "[Event \"E\"]\n\n1. e4 *", []string{"missing-tag"}},
+		{"tag order", strings.Replace(lintRoster, "[Event \"E\"]\n", "", 1) + "[Event \"E\"]\n1. e4 1-0", []string{"tag-order"}},
+		{"extra tag first", "[ECO \"C50\"]\n" + lintRoster + "1. e4 1-0", []string{"tag-order"}},
+		{"duplicate tag", lintRoster + "[Site \"T\"]\n1. e4 1-0", []string{"duplicate-tag"}},
+		{"invalid tag value", strings.Replace(lintRoster, "2023.12.06", "06/12/2023", 1) + "1. e4 1-0", []string{"invalid-tag-value"}},
+		{"result mismatch", lintRoster + "1. e4 0-1", []string{"result-mismatch"}},
+		{"missing result", lintRoster + "1. e4 e5", []string{"missing-result"}},
+		{"comment after the result", lintRoster + "1. e4 e5 1-0 {trailing comment}", nil},
+		{"unterminated comment", lintRoster + "1. e4 {open 1-0", []string{"unbalanced-braces", "missing-result"}},
+		{"stray brace", lintRoster + "1. e4 } 1-0", []string{"unbalanced-braces"}},
+		{"unterminated variation", lintRoster + "1. e4 (1. d4 1-0", []string{"unbalanced-parens", "missing-result"}},
+		{"stray paren", lintRoster + "1. e4 ) 1-0", []string{"unbalanced-parens"}},
+		{"illegal move", lintRoster + "1. e4 e5 2. Ke3 1-0", []string{"illegal-move"}},
+		{"misplaced NAG", lintRoster + "$1 1. e4 1-0", []string{"syntax-error"}},
+		{"typos", lintRoster + "1. e4 ez5 2. Nf3 % Nc6 1-0", []string{"syntax-error", "syntax-error"}},
+		{"typo in a move", lintRoster + "1. e4 e5 2. Bz5 1-0", []string{"syntax-error"}},
+		{"invalid rank", lintRoster + "1. N9f3 1-0", []string{"syntax-error"}},
+		{"sorted by position", "[Event \"E\"]\n\n1. e4 ez5 *", []string{"missing-tag", "syntax-error"}},
+		{"non-standard move", lintRoster + "1. e4 e5 2. Ng1f3 Nc6 3. Bc4 Nf6 4. 0-0 1-0", []string{"non-standard-move", "non-standard-move"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range NewLinter().Lint(&Game{Raw: tt.input}) {
+				got = append(got, d.Rule)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.rules, ",") {
+				t.Errorf("Expected rules %v, got %v", tt.rules, got)
+			}
+		})
+	}
+}
+
+func TestLintPositions(t *testing.T) {
+	game := &Game{Raw: lintRoster + "1. e4 e5\n2. Ke3 1-0", Offset: 100, Line: 5}
+	diags := NewLinter().Lint(game)
+	if len(diags) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got %v", diags)
+	}
+	d := diags[0]
+	if d.Line != 14 || d.Column != 4 || d.Offset != int64(100+strings.Index(game.Raw, "Ke3")) {
+		t.Errorf("Unexpected position %d:%d offset %d", d.Line, d.Column, d.Offset)
+	}
+	if d.Severity != SeverityError || d.Message != "illegal move" {
+		t.Errorf("Unexpected diagnostic %+v", d)
+	}
+}
+
+func TestLinterEnableDisable(t *testing.T) {
+	game := &Game{Raw: "[Event \"E\"]\n1. e4 e5 2. Nf3 Nc6 3. Bc4 Nf6 4. 0-0"}
+	l := NewLinter()
+	if err := l.Disable("missing-tag", "non-standard-move"); err != nil {
+		t.Fatal(err)
+	}
+	if diags := l.Lint(game); len(diags) != 1 || diags[0].Rule != "missing-result" {
+		t.Errorf("Expected only missing-result, got %v", diags)
+	}
+	if err := l.Only("missing-tag"); err != nil {
+		t.Fatal(err)
+	}
+	if diags := l.Lint(game); len(diags) != 1 || diags[0].Rule != "missing-tag" {
+		t.Errorf("Expected only missing-tag, got %v", diags)
+	}
+	if err := l.Disable("no-such-rule"); err == nil {
+		t.Errorf("Expected an error for an unknown rule")
+	}
+}
+
+func TestLintCommand(t *testing.T) {
+	input := lintRoster + "1. e4 1-0\n\n" + lintRoster + "1. e4 0-1\n"
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"lint", "-json"}); err != errFailed {
+		t.Errorf("Expected the mismatch to fail the command, got %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &raw); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", stdout.String(), err)
+	}
+	if raw["rule"] != "result-mismatch" || raw["severity"] != "error" || raw["game"] != float64(2) || raw["line"] != float64(19) {
+		t.Errorf("Unexpected diagnostic %v", raw)
+	}
+
+	// Games without an Event tag are numbered one by one
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader("[White \"A\"]\n1. e4 *\n\n[White \"B\"]\n1. d4 Ke7 *\n"), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"lint", "-json", "-enable", "illegal-move"}); err != errFailed {
+		t.Errorf("Expected the illegal move to fail the command, got %v", err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &raw); err != nil || raw["game"] != float64(2) || raw["line"] != float64(5) {
+		t.Errorf("Expected the illegal move in game 2, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"lint", "-disable", "result-mismatch"}); err != nil {
+		t.Errorf("Expected no error with the rule disabled, got %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no diagnostics, got %q", stdout.String())
+	}
+}
+
+func TestLintFixtures(t *testing.T) {
+	for _, name := range []string{"single_game.pgn", "annotated_game.pgn"} {
+		data, err := os.ReadFile(filepath.Join("fixtures", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diags := NewLinter().Lint(&Game{Raw: string(data)}); len(diags) != 0 {
+			t.Errorf("%s: expected no diagnostics, got %v", name, diags)
+		}
+	}
+}
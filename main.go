@@ -1 +1,185 @@
 package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is a subcommand of the pgn tool
+type command struct {
+	name  string
+	usage string
+	run   func(e *env, args []string) error
+}
+
+// env holds the standard streams of a command so tests can replace them
+type env struct {
+	stdin    io.Reader
+	stdout   io.Writer
+	stderr   io.Writer
+	encoding Encoding // Of the games read, set with -encoding before the command
+}
+
+// errFailed reports a failure that the command already described on stderr
+var errFailed = errors.New("failed")
+
+func commands() []*command {
+	return []*command{
+		{name: "accuracy", usage: "average centipawn loss, accuracy and mistakes from %eval", run: runAccuracy},
+		{name: "analyze", usage: "evaluate every position with a UCI engine", run: runAnalyze},
+		{name: "clock", usage: "time spent per move and player from %clk and %emt", run: runClock},
+		{name: "dedup", usage: "remove duplicate games, keeping the most complete copy", run: runDedup},
+		{name: "eco", usage: "set ECO, Opening and Variation tags from the moves", run: runECO},
+		{name: "explore", usage: "opening tree of the moves played from a position", run: runExplore},
+		{name: "filter", usage: "select games by tags, result, length or ECO", run: runFilter},
+		{name: "fmt", usage: "rewrite games in export format", run: runFmt},
+		{name: "index", usage: "index a file to print games by number or tag without rescanning", run: runIndex},
+		{name: "json", usage: "convert PGN to NDJSON, or back with -r", run: runJSON},
+		{name: "lint", usage: "check games against the PGN standard", run: runLint},
+		{name: "material", usage: "find games by material signature, bishop pair or balance", run: runMaterial},
+		{name: "merge", usage: "concatenate games with normalised separators", run: runMerge},
+		{name: "search", usage: "find the games reaching a position, by any move order", run: runSearch},
+		{name: "split", usage: "split games into files by count, event, player or year", run: runSplit},
+		{name: "stats", usage: "player and event statistics", run: runStats},
+		{name: "strip", usage: "anonymise games for publishing, optionally dropping annotations", run: runStrip},
+	}
+}
+
+func main() {
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := run(e, os.Args[1:]); err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintln(e.stderr, "pgn:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(e *env, args []string) error {
+	global := newFlagSet(e, "pgn")
+	encoding := global.String("encoding", "auto", "encoding of the input: auto, utf-8, latin-1 or windows-1252")
+	global.Usage = func() { printUsage(e.stderr) }
+	if err := global.Parse(args); err != nil {
+		return helpRequested(err)
+	}
+	enc, err := ParseEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	e.encoding, args = enc, global.Args()
+
+	if len(args) == 0 {
+		printUsage(e.stderr)
+		return errFailed
+	}
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return helpRequested(cmd.run(e, args[1:]))
+		}
+	}
+	printUsage(e.stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// Helper to treat -h, for which the flag package already printed the usage,
+// as a success
+func helpRequested(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: pgn [-encoding name] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// Helper to create the flag set of a command, reporting errors on stderr
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// stringList is a flag that can be repeated, collecting every value
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// forEachInput calls fn with each named file in turn, or with stdin when no
+// file is given. Compressed inputs are decompressed and each PGN entry of a
+// zip archive is named "archive.zip:entry.pgn".
+func forEachInput(e *env, paths []string, fn func(name string, r io.Reader) error) error {
+	return forEachFile(e, paths, func(name string, r io.Reader) error {
+		return ReadPGN(r, func(entry string, r io.Reader) error {
+			return fn(entryName(name, entry), r)
+		})
+	})
+}
+
+// forEachFile calls fn with each named file as it is, or with stdin
+func forEachFile(e *env, paths []string, fn func(name string, r io.Reader) error) error {
+	if len(paths) == 0 {
+		return fn("<stdin>", e.stdin)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = fn(path, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper to name an input or an entry of an archive
+func entryName(name, entry string) string {
+	if entry == "" {
+		return name
+	}
+	return name + ":" + entry
+}
+
+// reportGameError prints an error in a scanned game to stderr, a PGNError as
+// a compiler-style diagnostic quoting the line of the game it is on
+func reportGameError(e *env, name string, n int, game *Game, err error) {
+	var pgnErr *PGNError
+	if !errors.As(err, &pgnErr) {
+		fmt.Fprintf(e.stderr, "%s: game %d: %v\n", name, n, err)
+		return
+	}
+	for _, d := range gameDiagnostics(game, pgnErr) {
+		d.File, d.Game = name, n
+		fmt.Fprint(e.stderr, d.Render(wantColor(e.stderr)))
+	}
+}
+
+// forEachGame scans every game of the inputs, calling fn with the game and its
+// number in the input or archive entry (starting at 1)
+func forEachGame(e *env, paths []string, fn func(name string, n int, game *Game) error) error {
+	return forEachFile(e, paths, func(name string, r io.Reader) error {
+		return ScanGames(r, e.encoding, func(n int, game *Game) error {
+			return fn(entryName(name, game.Entry), n, game)
+		})
+	})
+}
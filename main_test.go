@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHelpFlag(t *testing.T) {
+	for _, args := range [][]string{{"-h"}, {"lint", "-h"}, {"analyze", "-help"}} {
+		var stderr bytes.Buffer
+		e := &env{stdin: strings.NewReader(""), stdout: &bytes.Buffer{}, stderr: &stderr}
+		if err := run(e, args); err != nil {
+			t.Errorf("%v: expected help to succeed, got %v", args, err)
+		}
+		if !strings.HasPrefix(stderr.String(), "usage: pgn") {
+			t.Errorf("%v: expected the usage, got %q", args, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Order of the pieces in material signatures, kings first
+const materialLetters = "KQRBNP"
+
+// Values of the pieces in pawns, in materialLetters order
+var materialValues = [6]int{0, 9, 5, 3, 3, 1}
+
+// SideMaterial counts the pieces of one side
+type SideMaterial struct {
+	Pieces       [6]int // In materialLetters order
+	LightBishops int
+	DarkBishops  int
+}
+
+// Count returns the number of pieces of a kind (K, Q, R, B, N or P)
+func (s SideMaterial) Count(kind byte) int {
+	if i := strings.IndexByte(materialLetters, kind); i >= 0 {
+		return s.Pieces[i]
+	}
+	return 0
+}
+
+// Value returns the material in pawns, counting 9 for a queen, 5 for a rook
+// and 3 for a bishop or knight
+func (s SideMaterial) Value() int {
+	value := 0
+	for i, n := range s.Pieces {
+		value += n * materialValues[i]
+	}
+	return value
+}
+
+// BishopPair reports whether the side has bishops on both square colours
+func (s SideMaterial) BishopPair() bool {
+	return s.LightBishops > 0 && s.DarkBishops > 0
+}
+
+// Helper to write the signature of one side, such as KRPP
+func (s SideMaterial) signature(sb *strings.Builder) {
+	for i, n := range s.Pieces {
+		for range n {
+			sb.WriteByte(materialLetters[i])
+		}
+	}
+}
+
+// Material is the material of both sides of a position
+type Material struct {
+	White, Black SideMaterial
+}
+
+// Material counts the pieces of the position
+func (p *Position) Material() Material {
+	var m Material
+	for sq, piece := range p.board {
+		if piece == 0 {
+			continue
+		}
+		side := &m.White
+		if pieceColor(piece) == Black {
+			side = &m.Black
+		}
+		kind := pieceKind(piece)
+		side.Pieces[strings.IndexByte(materialLetters, kind)]++
+		if kind == 'B' {
+			if s := Square(sq); (s.File()+s.Rank())%2 == 1 {
+				side.LightBishops++
+			} else {
+				side.DarkBishops++
+			}
+		}
+	}
+	return m
+}
+
+// Signature returns the material signature, such as KRPPvKR
+func (m Material) Signature() string {
+	var sb strings.Builder
+	m.White.signature(&sb)
+	sb.WriteByte('v')
+	m.Black.signature(&sb)
+	return sb.String()
+}
+
+// Balance returns White's material minus Black's, in pawns
+func (m Material) Balance() int {
+	return m.White.Value() - m.Black.Value()
+}
+
+// swap returns the material with the colours exchanged
+func (m Material) swap() Material {
+	return Material{White: m.Black, Black: m.White}
+}
+
+// MaterialCondition is a condition on the material of a position
+type MaterialCondition func(Material) bool
+
+// AllMaterial combines conditions that must all hold
+func AllMaterial(conditions ...MaterialCondition) MaterialCondition {
+	return func(m Material) bool {
+		for _, cond := range conditions {
+			if !cond(m) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sidePattern is one side of a material signature: exact counts, or minimum
+// counts for the pieces followed by +
+type sidePattern struct {
+	counts  [6]int
+	atLeast [6]bool
+}
+
+func (sp sidePattern) matches(s SideMaterial) bool {
+	for i, n := range s.Pieces {
+		if n != sp.counts[i] && !(sp.atLeast[i] && n >= sp.counts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SignatureCondition matches positions with a material signature such as
+// KRPvKR. A piece followed by + may appear more times, so KRP+vKR matches
+// rook and pawns against rook. With eitherColor the sides may be swapped.
+func SignatureCondition(signature string, eitherColor bool) (MaterialCondition, error) {
+	white, black, ok := strings.Cut(signature, "v")
+	if !ok {
+		return nil, fmt.Errorf("invalid material signature %q, expected such as KRPvKR", signature)
+	}
+	var patterns [2]sidePattern
+	for side, s := range []string{white, black} {
+		if !strings.HasPrefix(s, "K") || strings.Count(s, "K") != 1 {
+			return nil, fmt.Errorf("invalid material signature %q, each side needs one king", signature)
+		}
+		for j := 0; j < len(s); j++ {
+			i := strings.IndexByte(materialLetters, s[j])
+			if i < 0 {
+				return nil, fmt.Errorf("invalid piece %q in material signature %q", s[j], signature)
+			}
+			patterns[side].counts[i]++
+			if j+1 < len(s) && s[j+1] == '+' {
+				patterns[side].atLeast[i] = true
+				j++
+			}
+		}
+	}
+
+	match := func(m Material) bool {
+		return patterns[0].matches(m.White) && patterns[1].matches(m.Black)
+	}
+	return func(m Material) bool {
+		return match(m) || eitherColor && match(m.swap())
+	}, nil
+}
+
+// BishopPairCondition matches positions where a side has the bishop pair and
+// the other side does not
+func BishopPairCondition(c Color) MaterialCondition {
+	return func(m Material) bool {
+		if c == Black {
+			m = m.swap()
+		}
+		return m.White.BishopPair() && !m.Black.BishopPair()
+	}
+}
+
+// BalanceCondition matches positions where White's material minus Black's,
+// in pawns, is between low and high
+func BalanceCondition(low, high int) MaterialCondition {
+	return func(m Material) bool {
+		balance := m.Balance()
+		return balance >= low && balance <= high
+	}
+}
+
+// FindMaterial returns the first ply of the main line (0 for the starting
+// position) from which a condition held for at least minPlies consecutive
+// positions, counting the position it starts at
+func FindMaterial(g *ParsedGame, cond MaterialCondition, minPlies int) (int, bool) {
+	start, run := 0, 0
+	check := func(ply int, p *Position) bool {
+		if !cond(p.Material()) {
+			run = 0
+			return false
+		}
+		if run == 0 {
+			start = ply
+		}
+		run++
+		return run >= max(minPlies, 1)
+	}
+	if check(0, g.Root.Position) {
+		return start, true
+	}
+	for ply, node := range g.Mainline() {
+		if check(ply+1, node.Position) {
+			return start, true
+		}
+	}
+	return 0, false
+}
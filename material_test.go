@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const rookEnding = "[SetUp \"1\"]\n[FEN \"8/8/4k3/8/3P4/8/r7/4K2R w - - 0 1\"]\n\n1. Rh6+ Kd5 2. Rh5+ Kxd4 3. Rh4+ *"
+
+const bishopTrade = "1. e4 e6 2. d4 Bb4+ 3. c3 Bxc3+ 4. Nxc3 *"
+
+func TestMaterial(t *testing.T) {
+	m := NewPosition().Material()
+	if sig := m.Signature(); sig != "KQRRBBNNPPPPPPPPvKQRRBBNNPPPPPPPP" {
+		t.Errorf("Unexpected signature %q", sig)
+	}
+	if m.Balance() != 0 || m.White.Value() != 39 || !m.White.BishopPair() || !m.Black.BishopPair() || m.Black.Count('N') != 2 {
+		t.Errorf("Unexpected starting material %+v", m)
+	}
+}
+
+func TestFindMaterial(t *testing.T) {
+	signature := func(s string, either bool) MaterialCondition {
+		cond, err := SignatureCondition(s, either)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cond
+	}
+	tests := []struct {
+		name     string
+		game     string
+		cond     MaterialCondition
+		minPlies int
+		ply      int
+		found    bool
+	}{
+		{"exact signature", rookEnding, signature("KRPvKR", false), 1, 0, true},
+		{"after a capture", rookEnding, signature("KRvKR", false), 1, 4, true},
+		{"colours as given", rookEnding, signature("KRvKRP", false), 1, 0, false},
+		{"either colour", rookEnding, signature("KRvKRP", true), 1, 0, true},
+		{"one or more pawns", rookEnding, signature("KRP+vKR", false), 1, 0, true},
+		{"held long enough", rookEnding, signature("KRPvKR", false), 4, 0, true},
+		{"not held long enough", rookEnding, signature("KRPvKR", false), 5, 0, false},
+		{"bishop pair", bishopTrade, BishopPairCondition(White), 1, 7, true},
+		{"no bishop pair", bishopTrade, BishopPairCondition(Black), 1, 0, false},
+		{"balance", bishopTrade, BalanceCondition(2, math.MaxInt), 1, 7, true},
+		{"all conditions", bishopTrade, AllMaterial(BishopPairCondition(White), BalanceCondition(2, 2)), 1, 7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseGame(&Game{Raw: tt.game})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ply, found := FindMaterial(g, tt.cond, tt.minPlies); ply != tt.ply || found != tt.found {
+				t.Errorf("Expected %d %v, got %d %v", tt.ply, tt.found, ply, found)
+			}
+		})
+	}
+}
+
+func TestSignatureConditionErrors(t *testing.T) {
+	for _, s := range []string{"KRP", "RvK", "KRvKKR", "KXvK", ""} {
+		if _, err := SignatureCondition(s, false); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestMaterialCommand(t *testing.T) {
+	input := "[Event \"A\"]\n" + rookEnding + "\n\n[Event \"B\"]\n\n" + bishopTrade + "\n"
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"material", "-sig", "KRvKR"}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "<stdin>:1: game 1: ply 4 KRvKR\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"material", "-bishop-pair", "white", "-balance", "1:", "-pgn"}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "[Event \"B\"]\n\n"+bishopTrade+"\n\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+}
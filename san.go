@@ -0,0 +1,190 @@
+package main
+
+import (
+	"strings"
+)
+
+// SAN returns a legal move in Standard Algebraic Notation, including the
+// check or checkmate marker
+func (p *Position) SAN(m Move) string {
+	san := p.sanWithoutCheck(m)
+
+	next := p.Play(m)
+	if next.InCheck() {
+		if len(next.LegalMoves()) == 0 {
+			return san + "#"
+		}
+		return san + "+"
+	}
+	return san
+}
+
+func (p *Position) sanWithoutCheck(m Move) string {
+	piece := p.board[m.From]
+	kind := pieceKind(piece)
+
+	if kind == 'K' && m.To.File()-m.From.File() == 2 {
+		return "O-O"
+	}
+	if kind == 'K' && m.From.File()-m.To.File() == 2 {
+		return "O-O-O"
+	}
+
+	var sb strings.Builder
+	capture := p.board[m.To] != 0
+
+	if kind == 'P' {
+		if m.From.File() != m.To.File() {
+			sb.WriteByte(byte('a' + m.From.File()))
+			capture = true
+		}
+	} else {
+		sb.WriteByte(kind)
+		sb.WriteString(p.disambiguation(m, piece))
+	}
+
+	if capture {
+		sb.WriteByte('x')
+	}
+	sb.WriteString(m.To.String())
+	if m.Promotion != 0 {
+		sb.WriteByte('=')
+		sb.WriteByte(m.Promotion)
+	}
+	return sb.String()
+}
+
+// Helper to find the shortest prefix that tells a piece move apart from
+// moves of the same kind of piece to the same square
+func (p *Position) disambiguation(m Move, piece byte) string {
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range p.LegalMoves() {
+		if other.To != m.To || other.From == m.From || p.board[other.From] != piece {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || other.From.File() == m.From.File()
+		sameRank = sameRank || other.From.Rank() == m.From.Rank()
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return m.From.String()[:1]
+	case !sameRank:
+		return m.From.String()[1:]
+	}
+	return m.From.String()
+}
+
+// ParseSAN resolves a move written in algebraic notation against the legal
+// moves of the position. It accepts the usual variations found in import
+// format: annotations, 0-0 castling, missing or extra disambiguation, long
+// algebraic (Ng1-f3, e2e4) and promotions with or without '='.
+func (p *Position) ParseSAN(san string) (Move, error) {
+	s := strings.TrimRight(san, "+#!?")
+
+	switch s {
+	case "O-O", "0-0":
+		return p.castle(6)
+	case "O-O-O", "0-0-0":
+		return p.castle(2)
+	}
+
+	var promotion byte
+	if i := strings.IndexByte(s, '='); i >= 0 && i+1 < len(s) {
+		promotion = s[i+1]
+		s = s[:i]
+	} else if n := len(s); n > 2 && strings.IndexByte("QRBN", s[n-1]) >= 0 && isDigit(s[n-2]) {
+		promotion = s[n-1]
+		s = s[:n-1]
+	}
+
+	kind := byte('P')
+	if len(s) > 0 && strings.IndexByte("PNBRQK", s[0]) >= 0 {
+		kind = s[0]
+		s = s[1:]
+	}
+	s = strings.NewReplacer("x", "", "-", "", ":", "").Replace(s)
+
+	if len(s) < 2 || len(s) > 4 {
+		return Move{}, ErrIllegalMove(0)
+	}
+	to, ok := parseSquare(s[len(s)-2:])
+	if !ok {
+		return Move{}, ErrIllegalMove(0)
+	}
+	from := s[:len(s)-2]
+
+	var found []Move
+	for _, m := range p.LegalMoves() {
+		if m.To != to || pieceKind(p.board[m.From]) != kind || m.Promotion != promotion {
+			continue
+		}
+		if !matchesFrom(m.From, from) {
+			continue
+		}
+		found = append(found, m)
+	}
+
+	switch len(found) {
+	case 0:
+		return Move{}, ErrIllegalMove(0)
+	case 1:
+		return found[0], nil
+	}
+	return Move{}, ErrAmbiguousMove(0)
+}
+
+// Helper to check a square against a (possibly partial) origin like "b", "4" or "b4"
+func matchesFrom(sq Square, from string) bool {
+	for i := 0; i < len(from); i++ {
+		switch ch := from[i]; {
+		case isFile(ch):
+			if sq.File() != int(ch-'a') {
+				return false
+			}
+		case isRank(ch):
+			if sq.Rank() != int(ch-'1') {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Position) castle(file int) (Move, error) {
+	king := p.kingSquare(p.turn)
+	if king == NoSquare {
+		return Move{}, ErrIllegalMove(0)
+	}
+	m := Move{From: king, To: newSquare(file, king.Rank())}
+	if king.File() != 4 || !p.IsLegal(m) {
+		return Move{}, ErrIllegalMove(0)
+	}
+	return m, nil
+}
+
+// ParseUCI resolves a move in UCI notation (e.g. e2e4, e7e8q) against the
+// legal moves of the position
+func (p *Position) ParseUCI(uci string) (Move, error) {
+	if len(uci) != 4 && len(uci) != 5 {
+		return Move{}, ErrIllegalMove(0)
+	}
+	from, ok1 := parseSquare(uci[:2])
+	to, ok2 := parseSquare(uci[2:4])
+	if !ok1 || !ok2 {
+		return Move{}, ErrIllegalMove(0)
+	}
+	m := Move{From: from, To: to}
+	if len(uci) == 5 {
+		m.Promotion = pieceKind(uci[4])
+	}
+	if !p.IsLegal(m) {
+		return Move{}, ErrIllegalMove(0)
+	}
+	return m, nil
+}
@@ -4,10 +4,16 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 type Game struct {
-	Raw string
+	Raw    string
+	Offset int64  // Byte offset of Raw in the input
+	Line   int    // Line number (starting at 1) of the first line of Raw in the input
+	Size   int    // Length of the game in the input, before conversion to UTF-8
+	Entry  string // Name of the zip archive entry the game was read from, if any
 }
 
 // TokenizeGame function to tokenize a PGN game
@@ -32,34 +38,75 @@ func TokenizeGame(game *Game) ([]Token, error) {
 
 type Scanner struct {
 	scanner   *bufio.Scanner
-	nextGame  *Game // Buffer for peeked game
-	lastError error // Store last error
+	nextGame  *Game   // Buffer for peeked game
+	pending   []*Game // Further games of the last scanned text
+	lastError error   // Store last error
+
+	consumed   int64 // Bytes consumed from the input so far
+	line       int   // Line number at the consumed position
+	gameOffset int64 // Offset of the last scanned game
+	gameLine   int   // Line of the last scanned game
+
+	encoding   Encoding
+	bomChecked bool
 }
 
-// NewScanner function to create a new PGN scanner
+// NewScanner function to create a new PGN scanner. Games are converted to
+// UTF-8, detecting Windows-1252 text unless SetEncoding says otherwise. A
+// byte order mark is skipped, and UTF-16 input with one is converted.
 func NewScanner(r io.Reader) *Scanner {
-	s := bufio.NewScanner(r)
-	s.Split(splitPGNGames)
-	return &Scanner{scanner: s}
+	s := &Scanner{scanner: bufio.NewScanner(&bomReader{r: r}), line: 1}
+	s.scanner.Split(s.split)
+	return s
+}
+
+// SetEncoding sets the encoding of the input, EncodingAuto by default
+func (s *Scanner) SetEncoding(enc Encoding) {
+	s.encoding = enc
+}
+
+// split wraps splitPGNGames to keep track of where each game starts
+func (s *Scanner) split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if !s.bomChecked {
+		if len(data) < len(utf8BOM) && !atEOF && bytes.HasPrefix(utf8BOM, data) {
+			return 0, nil, nil // Not enough data to tell
+		}
+		s.bomChecked = true
+		if bytes.HasPrefix(data, utf8BOM) {
+			s.consumed += int64(len(utf8BOM))
+			return len(utf8BOM), nil, nil
+		}
+	}
+
+	advance, token, err = splitPGNGames(data, atEOF)
+	if token != nil {
+		// token is a subslice of data, so the difference in capacity is its offset
+		start := cap(data) - cap(token)
+		s.gameOffset = s.consumed + int64(start)
+		s.gameLine = s.line + bytes.Count(data[:start], []byte{'\n'})
+	}
+	s.consumed += int64(advance)
+	s.line += bytes.Count(data[:advance], []byte{'\n'})
+	return advance, token, err
+}
+
+// Helper to build the game that was just scanned
+func (s *Scanner) game() *Game {
+	raw := s.scanner.Bytes()
+	return &Game{Raw: DecodeText(raw, s.encoding), Offset: s.gameOffset, Line: s.gameLine, Size: len(raw)}
 }
 
 // ScanGame function to scan the next PGN game
 func (s *Scanner) ScanGame() (*Game, error) {
-	// If we have a buffered game from HasNext(), return it
-	if s.nextGame != nil {
+	if s.HasNext() {
 		game := s.nextGame
 		s.nextGame = nil
 		return game, nil
 	}
 
-	// Otherwise scan the next game
-	if s.scanner.Scan() {
-		return &Game{Raw: s.scanner.Text()}, nil
-	}
-
 	// Check for errors
-	if err := s.scanner.Err(); err != nil {
-		return nil, err
+	if s.lastError != nil {
+		return nil, s.lastError
 	}
 	return nil, io.EOF
 }
@@ -71,16 +118,53 @@ func (s *Scanner) HasNext() bool {
 		return true
 	}
 
-	// Try to scan the next game
-	if s.scanner.Scan() {
-		// Store the game in the buffer
-		s.nextGame = &Game{Raw: s.scanner.Text()}
-		return true
+	// Scan more text once its games are used up
+	if len(s.pending) == 0 {
+		if !s.scanner.Scan() {
+			// Store any error that occurred
+			s.lastError = s.scanner.Err()
+			return false
+		}
+		s.pending = splitGames(s.game())
 	}
+	s.nextGame, s.pending = s.pending[0], s.pending[1:]
+	return true
+}
 
-	// Store any error that occurred
-	s.lastError = s.scanner.Err()
-	return false
+// splitGames splits scanned text into its games. The split function only
+// starts a new game at an Event tag, so games without one are scanned
+// together. Each game runs until the next one starts, and text after the last
+// game stays with it so that it is reported.
+func splitGames(text *Game) []*Game {
+	nodes := ParseSyntax(text.Raw).Games()
+	if len(nodes) < 2 {
+		return []*Game{text}
+	}
+	games := make([]*Game, len(nodes))
+	for i, node := range nodes {
+		start, end := node.firstLeaf().Token.Pos, len(text.Raw)
+		if i+1 < len(nodes) {
+			end = nodes[i+1].firstLeaf().Token.Pos
+		}
+		raw := strings.TrimSpace(text.Raw[start:end])
+		games[i] = &Game{
+			Raw:    raw,
+			Offset: text.Offset + int64(text.inputPos(start)),
+			Line:   text.Line + strings.Count(text.Raw[:start], "\n"),
+			Size:   text.inputPos(start+len(raw)) - text.inputPos(start),
+			Entry:  text.Entry,
+		}
+	}
+	return games
+}
+
+// inputPos maps a byte position in Raw to the input it was converted from.
+// Text decoded from a single-byte encoding has one rune per input byte.
+func (g *Game) inputPos(pos int) int {
+	if g.Size == 0 || g.Size == len(g.Raw) {
+		return pos
+	}
+	return utf8.RuneCountInString(g.Raw[:pos])
 }
 
 // Split function for bufio.Scanner to split PGN games
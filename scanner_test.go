@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -229,3 +230,64 @@ func TestHasNextDoesntConsume(t *testing.T) {
 		t.Error("First game has no tokens after multiple HasNext calls")
 	}
 }
+
+func TestScannerGamePositions(t *testing.T) {
+	input := "\n\n[Event \"A\"]\n1. e4 1-0\n\n\n[Event \"B\"]\r\n1. d4 0-1\n"
+	scanner := NewScanner(strings.NewReader(input))
+
+	expected := []struct {
+		offset int64
+		line   int
+	}{
+		{2, 3},
+		{26, 7},
+	}
+	for i, exp := range expected {
+		game, err := scanner.ScanGame()
+		if err != nil {
+			t.Fatalf("Failed to read game %d: %v", i+1, err)
+		}
+		if game.Offset != exp.offset || game.Line != exp.line {
+			t.Errorf("Game %d - Expected offset %d line %d, got offset %d line %d", i+1, exp.offset, exp.line, game.Offset, game.Line)
+		}
+		if !strings.HasPrefix(input[game.Offset:], game.Raw) {
+			t.Errorf("Game %d - Raw text is not found at its offset", i+1)
+		}
+	}
+}
+
+func TestScannerGamesWithoutEvent(t *testing.T) {
+	// The split function keeps these together, the scanner returns each game
+	input := "[White \"A\"]\n1. e4 1-0 {won}\n\n[White \"B\"]\n1. d4 Ke7 *\n1. c4 {open"
+	scanner := NewScanner(strings.NewReader(input))
+
+	expected := []struct {
+		raw    string
+		offset int64
+		line   int
+	}{
+		{"[White \"A\"]\n1. e4 1-0 {won}", 0, 1},
+		{"[White \"B\"]\n1. d4 Ke7 *", 29, 4},
+		{"1. c4 {open", 53, 6},
+	}
+	for i, exp := range expected {
+		game, err := scanner.ScanGame()
+		if err != nil {
+			t.Fatalf("Failed to read game %d: %v", i+1, err)
+		}
+		if game.Raw != exp.raw || game.Offset != exp.offset || game.Line != exp.line || game.Size != len(exp.raw) {
+			t.Errorf("Game %d - Expected %q at offset %d line %d, got %+v", i+1, exp.raw, exp.offset, exp.line, game)
+		}
+	}
+	if _, err := scanner.ScanGame(); err != io.EOF {
+		t.Errorf("Expected the end of the input, got %v", err)
+	}
+
+	// Offsets count the bytes of converted text
+	latin1 := "[White \"M\xfcller\"]\n1. e4 *\n[White \"B\"]\n1. d4 *"
+	scanner = NewScanner(strings.NewReader(latin1))
+	scanner.ScanGame()
+	if game, err := scanner.ScanGame(); err != nil || game.Offset != 25 || latin1[game.Offset:] != "[White \"B\"]\n1. d4 *" {
+		t.Errorf("Expected the second game at offset 25, got %+v %v", game, err)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// PositionMatch is a game that reached a searched position
+type PositionMatch struct {
+	GameRef
+	Ply int // Plies played when the position was first reached, 0 for the start
+}
+
+// FindPosition returns the first ply of the main line at which a game reached
+// a position, by any move order. Ply 0 is the starting position.
+func FindPosition(g *ParsedGame, p *Position) (int, bool) {
+	hash := p.Hash()
+	if g.Root.Position.Hash() == hash {
+		return 0, true
+	}
+	for ply, node := range g.Mainline() {
+		if node.Position.Hash() == hash {
+			return ply + 1, true
+		}
+	}
+	return 0, false
+}
+
+// PositionIndex maps every position reached in the main lines of a set of
+// games to the games reaching it, for repeated searches. It records the
+// stamps of the files added with AddSource so that a stale index is detected.
+type PositionIndex struct {
+	sources   []indexedSource
+	games     []GameRef
+	positions map[uint64][]indexedPosition
+}
+
+// indexedSource is a file whose games are indexed, as it was when read
+type indexedSource struct {
+	Path  string
+	Stamp fileStamp
+}
+
+// indexedPosition is the first occurrence of a position in a game
+type indexedPosition struct {
+	Game int // Index in games
+	Ply  int
+}
+
+// NewPositionIndex creates an empty index
+func NewPositionIndex() *PositionIndex {
+	return &PositionIndex{positions: map[uint64][]indexedPosition{}}
+}
+
+// Add indexes the positions of a game's main line
+func (x *PositionIndex) Add(ref GameRef, g *ParsedGame) {
+	game := len(x.games)
+	x.games = append(x.games, ref)
+
+	seen := map[uint64]bool{}
+	add := func(ply int, p *Position) {
+		hash := p.Hash()
+		if !seen[hash] {
+			seen[hash] = true
+			x.positions[hash] = append(x.positions[hash], indexedPosition{game, ply})
+		}
+	}
+	add(0, g.Root.Position)
+	for ply, node := range g.Mainline() {
+		add(ply+1, node.Position)
+	}
+}
+
+// AddSource records the size, modification time and checksum of a file whose
+// games are added, to be compared by Check. It is called before reading the
+// file so that changes made meanwhile are noticed.
+func (x *PositionIndex) AddSource(path string) error {
+	stamp, err := stampFile(path)
+	if err != nil {
+		return err
+	}
+	x.sources = append(x.sources, indexedSource{path, stamp})
+	return nil
+}
+
+// Sources returns the files added with AddSource
+func (x *PositionIndex) Sources() []string {
+	paths := make([]string, len(x.sources))
+	for i, source := range x.sources {
+		paths[i] = source.Path
+	}
+	return paths
+}
+
+// Check returns ErrStaleIndex if a file added with AddSource changed since
+func (x *PositionIndex) Check() error {
+	for _, source := range x.sources {
+		stamp, err := stampFile(source.Path)
+		if err != nil {
+			return err
+		}
+		if stamp != source.Stamp {
+			return fmt.Errorf("%s: %w", source.Path, ErrStaleIndex)
+		}
+	}
+	return nil
+}
+
+// Games returns the number of games indexed
+func (x *PositionIndex) Games() int {
+	return len(x.games)
+}
+
+// Search returns the games that reached a position, in the order they were added
+func (x *PositionIndex) Search(p *Position) []PositionMatch {
+	entries := x.positions[p.Hash()]
+	matches := make([]PositionMatch, 0, len(entries))
+	for _, entry := range entries {
+		matches = append(matches, PositionMatch{GameRef: x.games[entry.Game], Ply: entry.Ply})
+	}
+	return matches
+}
+
+// positionIndexFile is the encoded form of a PositionIndex
+type positionIndexFile struct {
+	Version   int
+	Sources   []indexedSource
+	Games     []GameRef
+	Positions map[uint64][]indexedPosition
+}
+
+// Version of the position index encoding, changed whenever it changes
+const positionIndexVersion = 2
+
+// WriteTo saves the index in a binary format read by ReadPositionIndex
+func (x *PositionIndex) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := gob.NewEncoder(cw).Encode(positionIndexFile{Version: positionIndexVersion, Sources: x.sources, Games: x.games, Positions: x.positions})
+	return cw.n, err
+}
+
+// ReadPositionIndex loads an index saved with WriteTo
+func ReadPositionIndex(r io.Reader) (*PositionIndex, error) {
+	var f positionIndexFile
+	if err := gob.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid position index: %w", err)
+	}
+	if f.Version != positionIndexVersion {
+		return nil, fmt.Errorf("unsupported position index version %d", f.Version)
+	}
+	if f.Positions == nil {
+		f.Positions = map[uint64][]indexedPosition{}
+	}
+	return &PositionIndex{sources: f.Sources, games: f.Games, positions: f.Positions}, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const searchInput = `[Event "A"]
+
+1. d4 d5 2. c4 e6 *
+
+[Event "B"]
+
+1. c4 e6 2. d4 d5 3. Nc3 *
+
+[Event "C"]
+
+1. e4 e5 *
+`
+
+func TestFindPosition(t *testing.T) {
+	qgd, _ := PlayMoves(NewPosition(), "d4 d5 c4 e6")
+	tests := []struct {
+		moves string
+		ply   int
+		found bool
+	}{
+		{"1. d4 d5 2. c4 e6 3. Nc3", 4, true},
+		{"1. c4 e6 2. d4 d5", 4, true},
+		{"1. Nf3 d5 2. d4 e6 3. c4", 0, false},
+	}
+	for _, tt := range tests {
+		g, err := ParseGame(&Game{Raw: tt.moves + " *"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ply, found := FindPosition(g, qgd); ply != tt.ply || found != tt.found {
+			t.Errorf("%s: expected %d %v, got %d %v", tt.moves, tt.ply, tt.found, ply, found)
+		}
+	}
+
+	g, _ := ParseGame(&Game{Raw: "1. e4 *"})
+	if ply, found := FindPosition(g, NewPosition()); ply != 0 || !found {
+		t.Errorf("Expected the starting position at ply 0, got %d %v", ply, found)
+	}
+}
+
+func TestPositionIndex(t *testing.T) {
+	x := NewPositionIndex()
+	for i, node := range ParseSyntax(searchInput).Games() {
+		g, err := parseGameSyntax(node, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.Add(GameRef{File: "db.pgn", Number: i + 1}, g)
+	}
+
+	qgd, _ := PlayMoves(NewPosition(), "d4 d5 c4 e6")
+	want := []PositionMatch{{GameRef{File: "db.pgn", Number: 1}, 4}, {GameRef{File: "db.pgn", Number: 2}, 4}}
+	if got := x.Search(qgd); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := x.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadPositionIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Search(qgd); loaded.Games() != 3 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the loaded index to match, got %d games and %+v", loaded.Games(), got)
+	}
+	if _, err := ReadPositionIndex(strings.NewReader("not an index")); err == nil {
+		t.Errorf("Expected an error for an invalid index")
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	index := filepath.Join(t.TempDir(), "db.idx")
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(searchInput), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"search", "-moves", "d4 d5 c4 e6", "-save-index", index}); err != nil {
+		t.Fatal(err)
+	}
+	want := "<stdin>:1: game 1: ply 4\n<stdin>:5: game 2: ply 4\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%q\ngot:\n%q", want, stdout.String())
+	}
+
+	stdout.Reset()
+	fen := "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"
+	e = &env{stdin: strings.NewReader(""), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"search", "-index", index, "-fen", fen}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "<stdin>:9: game 3: ply 2\n" {
+		t.Errorf("Unexpected matches from the index %q", stdout.String())
+	}
+}
+
+func TestSearchStaleIndex(t *testing.T) {
+	dir := t.TempDir()
+	path, index := filepath.Join(dir, "db.pgn"), filepath.Join(dir, "db.idx")
+	if err := os.WriteFile(path, []byte(searchInput), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(""), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"search", "-save-index", index, path}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The games change after the index was saved
+	edited := strings.Replace(searchInput, "1. e4 e5 *", "1. d4 d5 2. c4 e6 *", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(e, []string{"search", "-index", index, "-moves", "d4 d5 c4 e6"}); err != nil {
+		t.Fatal(err)
+	}
+	want := path + ":1: game 1: ply 4\n" + path + ":5: game 2: ply 4\n" + path + ":9: game 3: ply 4\n"
+	if stdout.String() != want {
+		t.Errorf("Expected matches from the rebuilt index:\n%q\ngot:\n%q", want, stdout.String())
+	}
+
+	// The rebuilt index was saved
+	f, err := os.Open(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	x, err := ReadPositionIndex(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Check(); err != nil || !reflect.DeepEqual(x.Sources(), []string{path}) {
+		t.Errorf("Expected a fresh index of %s, got %v %v", path, x.Sources(), err)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SplitMode chooses how a Splitter distributes games over files
+type SplitMode int
+
+const (
+	SplitCount  SplitMode = iota // A new file every N games
+	SplitEvent                   // One file per Event tag
+	SplitPlayer                  // One file per player, each game going to both players' files
+	SplitYear                    // One file per year of the Date tag
+)
+
+// defaultSplitTemplates name the files of each mode. Placeholders are {n} for
+// the part number, {player} and {year} for the value being split on, and
+// {Tag} for the value of any tag of the game.
+var defaultSplitTemplates = map[SplitMode]string{
+	SplitCount:  "part-{n}.pgn",
+	SplitEvent:  "{Event}.pgn",
+	SplitPlayer: "{player}.pgn",
+	SplitYear:   "{year}.pgn",
+}
+
+// Maximum number of output files a Splitter keeps open at once
+const maxOpenSplitFiles = 64
+
+// Splitter writes games to files named from a template, one game at a time.
+// Files are created on first use and appended to afterwards.
+type Splitter struct {
+	Dir      string
+	Template string // Defaults to the template of the mode
+	Mode     SplitMode
+	Count    int // Games per file for SplitCount
+
+	games   int
+	created map[string]bool     // Files created by this splitter
+	open    map[string]*os.File // Files currently open
+	order   []string            // Open files, oldest first
+}
+
+// NewSplitter creates a splitter writing to dir
+func NewSplitter(dir string, mode SplitMode, count int) *Splitter {
+	return &Splitter{Dir: dir, Mode: mode, Count: count, created: map[string]bool{}, open: map[string]*os.File{}}
+}
+
+// Write adds a scanned game to its files
+func (s *Splitter) Write(game *Game) error {
+	node := ParseSyntax(game.Raw).Game()
+	if node == nil {
+		return nil
+	}
+	names, err := s.names(node)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		f, err := s.file(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, game.Raw+"\n\n"); err != nil {
+			return err
+		}
+	}
+	s.games++
+	return nil
+}
+
+// Helper to name the files a game goes to
+func (s *Splitter) names(game *SyntaxNode) ([]string, error) {
+	template := s.Template
+	if template == "" {
+		template = defaultSplitTemplates[s.Mode]
+	}
+
+	vars := map[string]string{}
+	var values []string // Values of the variable being split on
+	switch s.Mode {
+	case SplitCount:
+		if s.Count <= 0 {
+			return nil, fmt.Errorf("invalid number of games per file %d", s.Count)
+		}
+		vars["n"] = strconv.Itoa(s.games/s.Count + 1)
+	case SplitPlayer:
+		for _, side := range []string{"White", "Black"} {
+			name, _ := game.Tag(side)
+			values = append(values, name)
+		}
+	case SplitYear:
+		date, _ := game.Tag("Date")
+		d, _ := ParseDate(date)
+		year := ""
+		if d.Year != 0 {
+			year = strconv.Itoa(d.Year)
+		}
+		values = append(values, year)
+	}
+
+	if len(values) == 0 {
+		return []string{ExpandTemplate(template, game, vars)}, nil
+	}
+	var names []string
+	for _, value := range values {
+		vars["player"], vars["year"] = value, value
+		name := ExpandTemplate(template, game, vars)
+		if len(names) == 0 || names[0] != name {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Helper to open an output file, creating it on first use and closing the
+// oldest open file when too many are open
+func (s *Splitter) file(name string) (*os.File, error) {
+	if f, ok := s.open[name]; ok {
+		return f, nil
+	}
+	if len(s.order) >= maxOpenSplitFiles {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		err := s.open[oldest].Close()
+		delete(s.open, oldest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !s.created[name] {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(filepath.Join(s.Dir, name), flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.created[name] = true
+	s.open[name] = f
+	s.order = append(s.order, name)
+	return f, nil
+}
+
+// Close closes every open file
+func (s *Splitter) Close() error {
+	var err error
+	for _, name := range s.order {
+		if closeErr := s.open[name].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	s.open, s.order = map[string]*os.File{}, nil
+	return err
+}
+
+// ExpandTemplate replaces the {name} placeholders of a file name template with
+// vars, or else with the tag of that name. Values are made safe for file
+// names and missing ones become "unknown".
+func ExpandTemplate(template string, game *SyntaxNode, vars map[string]string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		length := -1
+		if start >= 0 {
+			length = strings.IndexByte(template[start:], '}')
+		}
+		if length < 0 {
+			sb.WriteString(template)
+			return sb.String()
+		}
+		end := start + length
+		sb.WriteString(template[:start])
+
+		name := template[start+1 : end]
+		value, ok := vars[name]
+		if !ok {
+			value, _ = game.Tag(name)
+		}
+		sb.WriteString(fileNameSafe(value))
+		template = template[end+1:]
+	}
+}
+
+// fileNameSafe turns a tag value into a file name component
+func fileNameSafe(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.Trim(s, "?-") == "" {
+		return "unknown"
+	}
+	var sb strings.Builder
+	underscore := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '.' {
+			sb.WriteRune(r)
+			underscore = false
+		} else if !underscore {
+			sb.WriteByte('_')
+			underscore = true
+		}
+	}
+	name := strings.Trim(sb.String(), "_")
+	if name == "" || name[0] == '.' {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const splitInput = "[Event \"Open A\"]\n[Date \"2020.05.01\"]\n[White \"Carlsen, Magnus\"]\n[Black \"Giri\"]\n\n1. e4 *\n\n\n\n" +
+	"[Event \"Open A\"]\n[Date \"2021.??.??\"]\n[White \"Giri\"]\n[Black \"So\"]\n\n1. d4 *\n" +
+	"[Event \"Cup/B\"]\n[Date \"????.??.??\"]\n[White \"So\"]\n[Black \"Giri\"]\n\n1. c4 *\n"
+
+func TestExpandTemplate(t *testing.T) {
+	game := ParseSyntax("[Event \"Tata Steel / Masters\"]\n[Round \"?\"]\n1. e4 *").Games()[0]
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{Event}.pgn", "Tata_Steel_Masters.pgn"},
+		{"{Event}-r{Round}-{n}.pgn", "Tata_Steel_Masters-runknown-7.pgn"},
+		{"{Site}.pgn", "unknown.pgn"},
+		{"games{.pgn", "games{.pgn"},
+	}
+	for _, tt := range tests {
+		if got := ExpandTemplate(tt.template, game, map[string]string{"n": "7"}); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.template, tt.want, got)
+		}
+	}
+	if got := fileNameSafe(".."); got != "_.." {
+		t.Errorf("Expected a safe name for .., got %q", got)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		args  []string
+		files map[string]int // File name and number of games
+	}{
+		{[]string{"-n", "2"}, map[string]int{"part-1.pgn": 2, "part-2.pgn": 1}},
+		{[]string{"-by", "event"}, map[string]int{"Open_A.pgn": 2, "Cup_B.pgn": 1}},
+		{[]string{"-by", "player"}, map[string]int{"Carlsen_Magnus.pgn": 1, "Giri.pgn": 3, "So.pgn": 2}},
+		{[]string{"-by", "year", "-o", "y{year}-{Event}.pgn"}, map[string]int{"y2020-Open_A.pgn": 1, "y2021-Open_A.pgn": 1, "yunknown-Cup_B.pgn": 1}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		var stderr bytes.Buffer
+		e := &env{stdin: strings.NewReader(splitInput), stdout: &bytes.Buffer{}, stderr: &stderr}
+		if err := run(e, append([]string{"split", "-dir", dir}, tt.args...)); err != nil {
+			t.Fatalf("%v: %v %s", tt.args, err, stderr.String())
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != len(tt.files) {
+			t.Errorf("%v: expected %d files, got %d", tt.args, len(tt.files), len(entries))
+		}
+		for name, games := range tt.files {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("%v: %v", tt.args, err)
+				continue
+			}
+			if got := strings.Count(string(data), "[Event "); got != games {
+				t.Errorf("%v: expected %d games in %s, got %d", tt.args, games, name, got)
+			}
+			if strings.Contains(string(data), "\n\n\n") || !strings.HasSuffix(string(data), "*\n\n") {
+				t.Errorf("%v: separators not normalised in %s: %q", tt.args, name, data)
+			}
+		}
+	}
+
+	e := &env{stdin: strings.NewReader(splitInput), stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"split", "-n", "2", "-by", "event"}); err == nil {
+		t.Errorf("Expected an error when combining -n and -by")
+	}
+}
+
+func TestMergeCommand(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.pgn")
+	b := filepath.Join(dir, "b.pgn")
+	os.WriteFile(a, []byte("\n\n[Event \"A\"]\n1. e4 *\n\n\n\n[Event \"B\"]\n1. d4 *"), 0o644)
+	os.WriteFile(b, []byte("[Event \"C\"]\r\n1. c4 *\r\n"), 0o644)
+
+	var stdout bytes.Buffer
+	e := &env{stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"merge", a, b}); err != nil {
+		t.Fatal(err)
+	}
+	want := "[Event \"A\"]\n1. e4 *\n\n[Event \"B\"]\n1. d4 *\n\n[Event \"C\"]\r\n1. c4 *\n\n"
+	if stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+
+	// Games without an Event tag are separated the same way
+	os.WriteFile(a, []byte("[Event \"A\"]\n1. e4 *\n\n\n\n\n[White \"B\"]\n1. d4 *\n[White \"C\"]\n1. c4 *\n"), 0o644)
+	stdout.Reset()
+	if err := run(e, []string{"merge", a}); err != nil {
+		t.Fatal(err)
+	}
+	want = "[Event \"A\"]\n1. e4 *\n\n[White \"B\"]\n1. d4 *\n\n[White \"C\"]\n1. c4 *\n\n"
+	if stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// ColorRecord is a player's results with one colour
+type ColorRecord struct {
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+}
+
+// PlayerStats are the totals of a player over finished games
+type PlayerStats struct {
+	Name               string      `json:"name"`
+	Games              int         `json:"games"`
+	White              ColorRecord `json:"white"`
+	Black              ColorRecord `json:"black"`
+	Score              float64     `json:"score"`                          // Points, a draw counting 0.5
+	ScorePercent       float64     `json:"score_percent"`                  // Score over the number of games
+	AverageOpponentElo float64     `json:"average_opponent_elo,omitempty"` // Over rated opponents only
+	Performance        int         `json:"performance,omitempty"`          // Over rated opponents only
+
+	ratedGames  int
+	ratedScore  float64
+	opponentElo int // Sum over rated opponents
+}
+
+// Wins returns the number of wins with both colours
+func (p *PlayerStats) Wins() int { return p.White.Wins + p.Black.Wins }
+
+// Draws returns the number of draws with both colours
+func (p *PlayerStats) Draws() int { return p.White.Draws + p.Black.Draws }
+
+// Losses returns the number of losses with both colours
+func (p *PlayerStats) Losses() int { return p.White.Losses + p.Black.Losses }
+
+// EventStats summarise the games of an event
+type EventStats struct {
+	Name       string  `json:"name"`
+	Games      int     `json:"games"`
+	WhiteWins  int     `json:"white_wins"`
+	Draws      int     `json:"draws"`
+	BlackWins  int     `json:"black_wins"`
+	Unfinished int     `json:"unfinished"`
+	Players    int     `json:"players"`
+	AverageElo float64 `json:"average_elo,omitempty"`
+	Start      string  `json:"start,omitempty"` // Earliest Date tag
+	End        string  `json:"end,omitempty"`   // Latest Date tag
+
+	players  map[string]bool
+	eloSum   int
+	eloCount int
+	start    Date
+	end      Date
+}
+
+// Stats collects player and event statistics from game tags
+type Stats struct {
+	players map[string]*PlayerStats
+	events  map[string]*EventStats
+}
+
+// NewStats creates an empty collection of statistics
+func NewStats() *Stats {
+	return &Stats{players: map[string]*PlayerStats{}, events: map[string]*EventStats{}}
+}
+
+// Add counts a game from its White, Black, Result, WhiteElo, BlackElo, Event
+// and Date tags. Unfinished games only count in the event summary.
+func (s *Stats) Add(game *SyntaxNode) {
+	white, _ := game.Tag("White")
+	black, _ := game.Tag("Black")
+	result, _ := game.Tag("Result")
+	whiteElo, _ := game.Tag("WhiteElo")
+	blackElo, _ := game.Tag("BlackElo")
+	event, _ := game.Tag("Event")
+	date, _ := game.Tag("Date")
+	whiteRating, _ := ParseElo(whiteElo) // 0 if unrated
+	blackRating, _ := ParseElo(blackElo)
+
+	e := s.event(statsName(event))
+	e.Games++
+	e.players[statsName(white)] = true
+	e.players[statsName(black)] = true
+	for _, rating := range []int{whiteRating, blackRating} {
+		if rating > 0 {
+			e.eloSum += rating
+			e.eloCount++
+		}
+	}
+	if d, err := ParseDate(date); err == nil && d.Year != 0 {
+		if e.start.Year == 0 || compareDates(d, e.start) < 0 {
+			e.start = d
+		}
+		if compareDates(d, e.end) > 0 {
+			e.end = d
+		}
+	}
+
+	var whiteScore float64
+	switch result {
+	case "1-0":
+		e.WhiteWins++
+		whiteScore = 1
+	case "0-1":
+		e.BlackWins++
+	case "1/2-1/2":
+		e.Draws++
+		whiteScore = 0.5
+	default:
+		e.Unfinished++
+		return
+	}
+
+	w, b := s.player(statsName(white)), s.player(statsName(black))
+	w.add(&w.White, whiteScore, blackRating)
+	b.add(&b.Black, 1-whiteScore, whiteRating)
+}
+
+// Helper to name unknown players and events
+func statsName(s string) string {
+	if s = strings.TrimSpace(s); strings.Trim(s, "?") == "" {
+		return "?"
+	}
+	return s
+}
+
+func (s *Stats) player(name string) *PlayerStats {
+	p, ok := s.players[name]
+	if !ok {
+		p = &PlayerStats{Name: name}
+		s.players[name] = p
+	}
+	return p
+}
+
+func (s *Stats) event(name string) *EventStats {
+	e, ok := s.events[name]
+	if !ok {
+		e = &EventStats{Name: name, players: map[string]bool{}}
+		s.events[name] = e
+	}
+	return e
+}
+
+// Helper to count a finished game in a player's record with one colour,
+// against an opponent rated opponentElo or 0 if unrated
+func (p *PlayerStats) add(record *ColorRecord, score float64, opponentElo int) {
+	p.Games++
+	p.Score += score
+	switch score {
+	case 1:
+		record.Wins++
+	case 0:
+		record.Losses++
+	default:
+		record.Draws++
+	}
+	if opponentElo > 0 {
+		p.ratedGames++
+		p.ratedScore += score
+		p.opponentElo += opponentElo
+	}
+}
+
+// Players returns the player totals, most games first, then by name
+func (s *Stats) Players() []*PlayerStats {
+	players := make([]*PlayerStats, 0, len(s.players))
+	for _, p := range s.players {
+		p.ScorePercent = roundTo(100*p.Score/float64(p.Games), 1)
+		p.AverageOpponentElo, p.Performance = 0, 0
+		if p.ratedGames > 0 {
+			average := float64(p.opponentElo) / float64(p.ratedGames)
+			p.AverageOpponentElo = roundTo(average, 1)
+			p.Performance = PerformanceRating(average, p.ratedScore/float64(p.ratedGames))
+		}
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Games != players[j].Games {
+			return players[i].Games > players[j].Games
+		}
+		return players[i].Name < players[j].Name
+	})
+	return players
+}
+
+// Events returns the event summaries sorted by name
+func (s *Stats) Events() []*EventStats {
+	events := make([]*EventStats, 0, len(s.events))
+	for _, e := range s.events {
+		e.Players = len(e.players)
+		e.AverageElo, e.Start, e.End = 0, "", ""
+		if e.eloCount > 0 {
+			e.AverageElo = roundTo(float64(e.eloSum)/float64(e.eloCount), 1)
+		}
+		if e.start.Year != 0 {
+			e.Start, e.End = e.start.String(), e.end.String()
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events
+}
+
+// Largest rating difference a performance can be away from the average
+// opponent, reached with a perfect or zero score
+const maxPerformanceDifference = 800
+
+// PerformanceRating returns the rating at which the Elo model expects a score
+// fraction against opponents of a given average rating:
+// average - 400 * log10(1/score - 1), capped at ±800.
+func PerformanceRating(averageOpponent, score float64) int {
+	diff := float64(maxPerformanceDifference)
+	if score <= 0 {
+		diff = -diff
+	} else if score < 1 {
+		diff = max(-diff, min(diff, -400*math.Log10(1/score-1)))
+	}
+	return int(math.Round(averageOpponent + diff))
+}
+
+func roundTo(x float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(x*scale) / scale
+}
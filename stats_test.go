@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const statsInput = `[Event "Open"]
+[Date "2021.03.02"]
+[White "A"]
+[Black "B"]
+[WhiteElo "2400"]
+[BlackElo "2200"]
+[Result "1-0"]
+
+1. e4 1-0
+
+[Event "Open"]
+[Date "2021.03.01"]
+[White "C"]
+[Black "A"]
+[WhiteElo "2300"]
+[BlackElo "2400"]
+[Result "1/2-1/2"]
+
+1. e4 1/2-1/2
+
+[Event "Open"]
+[Date "2021.03.03"]
+[White "B"]
+[Black "A"]
+[BlackElo "2400"]
+[Result "0-1"]
+
+1. e4 0-1
+
+[Event "Blitz"]
+[White "B"]
+[Black "C"]
+[Result "*"]
+
+1. e4 *
+`
+
+func collectStats(t *testing.T, input string) *Stats {
+	t.Helper()
+	stats := NewStats()
+	for _, node := range ParseSyntax(input).Games() {
+		stats.Add(node)
+	}
+	return stats
+}
+
+func TestPlayerStats(t *testing.T) {
+	players := collectStats(t, statsInput).Players()
+	if len(players) != 3 {
+		t.Fatalf("Expected 3 players, got %d", len(players))
+	}
+
+	a := players[0]
+	if a.Name != "A" || a.Games != 3 || a.Wins() != 2 || a.Draws() != 1 || a.Losses() != 0 {
+		t.Errorf("Unexpected totals for A: %+v", a)
+	}
+	if a.White != (ColorRecord{Wins: 1}) || a.Black != (ColorRecord{Wins: 1, Draws: 1}) {
+		t.Errorf("Unexpected colour records for A: %+v %+v", a.White, a.Black)
+	}
+	if a.Score != 2.5 || a.ScorePercent != 83.3 {
+		t.Errorf("Expected a score of 2.5 (83.3%%), got %v (%v%%)", a.Score, a.ScorePercent)
+	}
+	// B was unrated in the third game, so only two games count for ratings
+	if a.AverageOpponentElo != 2250 || a.Performance != 2441 {
+		t.Errorf("Expected 2250 and a performance of 2441, got %v and %d", a.AverageOpponentElo, a.Performance)
+	}
+
+	b := players[1]
+	if b.Name != "B" || b.Games != 2 || b.Score != 0 || b.Performance != 1600 {
+		t.Errorf("Unexpected totals for B: %+v", b)
+	}
+}
+
+func TestEventStats(t *testing.T) {
+	events := collectStats(t, statsInput).Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	blitz, open := events[0], events[1]
+	if blitz.Name != "Blitz" || blitz.Games != 1 || blitz.Unfinished != 1 || blitz.AverageElo != 0 || blitz.Start != "" {
+		t.Errorf("Unexpected blitz summary %+v", blitz)
+	}
+	want := EventStats{Name: "Open", Games: 3, WhiteWins: 1, Draws: 1, BlackWins: 1, Players: 3, AverageElo: 2340, Start: "2021.03.01", End: "2021.03.03"}
+	got := EventStats{Name: open.Name, Games: open.Games, WhiteWins: open.WhiteWins, Draws: open.Draws, BlackWins: open.BlackWins,
+		Players: open.Players, AverageElo: open.AverageElo, Start: open.Start, End: open.End}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestPerformanceRating(t *testing.T) {
+	tests := []struct {
+		average, score float64
+		want           int
+	}{
+		{2000, 0.5, 2000},
+		{2000, 0.75, 2191},
+		{2000, 0.25, 1809},
+		{2000, 1, 2800},
+		{2000, 0, 1200},
+		{2000, 0.999, 2800},
+	}
+	for _, tt := range tests {
+		if got := PerformanceRating(tt.average, tt.score); got != tt.want {
+			t.Errorf("PerformanceRating(%v, %v) = %d, expected %d", tt.average, tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	var stdout bytes.Buffer
+	e := &env{stdin: strings.NewReader(statsInput), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"stats", "-format", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if !strings.HasPrefix(lines[0], "player,games,wins") || lines[1] != "A,3,2,1,0,1,0,0,1,1,0,2.5,83.3,2250,2441" {
+		t.Errorf("Unexpected CSV %q", stdout.String())
+	}
+
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader(statsInput), stdout: &stdout, stderr: &bytes.Buffer{}}
+	if err := run(e, []string{"stats", "-by", "event", "-format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	var events []map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &events); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(events) != 2 || events[1]["name"] != "Open" || events[1]["white_wins"] != float64(1) {
+		t.Errorf("Unexpected JSON %s", stdout.String())
+	}
+	if _, ok := events[0]["average_elo"]; ok {
+		t.Errorf("Expected no average Elo for an unrated event")
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StripOptions selects what StripGame removes from a game
+type StripOptions struct {
+	Names      bool     // Replace player names by pseudonyms, dropping titles, FIDE IDs and teams
+	Ratings    bool     // Drop ratings and rating changes
+	Site       bool     // Blank the Site tag
+	Date       bool     // Blank the Date tag, dropping the other dates and times
+	Comments   bool     // Drop comment text, keeping any commands that are not stripped
+	Variations bool     // Drop variations
+	Clocks     bool     // Drop %clk, %emt, %egt and %mct commands
+	Evals      bool     // Drop %eval commands
+	Tags       []string // Further tags to drop
+}
+
+// DefaultStripOptions anonymises games: names, ratings, site and date
+var DefaultStripOptions = StripOptions{Names: true, Ratings: true, Site: true, Date: true}
+
+// Tags dropped by each option. Tags of the Seven Tag Roster are blanked
+// instead, so that stripped games still have them.
+var (
+	nameTags   = []string{"WhiteTitle", "BlackTitle", "WhiteFideId", "BlackFideId", "WhiteTeam", "BlackTeam", "WhiteNA", "BlackNA", "Annotator"}
+	ratingTags = []string{"WhiteElo", "BlackElo", "WhiteRatingDiff", "BlackRatingDiff", "WhiteUSCF", "BlackUSCF"}
+	dateTags   = []string{"EventDate", "UTCDate", "UTCTime", "Time", "EndDate", "StartTime", "EndTime"}
+	clockNames = []string{"clk", "emt", "egt", "mct"}
+)
+
+// Pseudonyms hands out a stable pseudonym per player name, "Player 1" for
+// the first name seen, "Player 2" for the next, and so on. Sharing one across
+// games keeps a player's pseudonym the same throughout a file.
+type Pseudonyms struct {
+	names map[string]string
+}
+
+// Name returns the pseudonym of a player. An unknown name ("?" or empty)
+// stays as is.
+func (p *Pseudonyms) Name(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "?" {
+		return name
+	}
+	if p.names == nil {
+		p.names = make(map[string]string)
+	}
+	pseudonym, ok := p.names[name]
+	if !ok {
+		pseudonym = "Player " + strconv.Itoa(len(p.names)+1)
+		p.names[name] = pseudonym
+	}
+	return pseudonym
+}
+
+// StripGame rewrites a game from its tokens without the information opts
+// selects, in export format. names gives player pseudonyms and may be nil
+// unless opts.Names is set. A game with a syntax error is not stripped, so
+// that unreadable text cannot leak through. game must hold a single game, as
+// the scanner returns them.
+func StripGame(game *Game, opts StripOptions, names *Pseudonyms) (string, error) {
+	st := &stripper{opts: opts, names: names, lexer: NewLexer(game.Raw)}
+	if err := st.run(); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, tag := range st.tags {
+		sb.WriteString("[" + tag.Key + " \"" + tagValueEscaper.Replace(tag.Value) + "\"]\n")
+	}
+	if len(st.tags) > 0 {
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(st.mw.wrap(exportLineWidth))
+	sb.WriteString("\n\n")
+	return sb.String(), nil
+}
+
+// stripper copies the tokens of a game, leaving out the stripped ones
+type stripper struct {
+	opts  StripOptions
+	names *Pseudonyms
+	lexer *Lexer
+	tags  []Tag
+	mw    movetextWriter
+
+	depth   int // Of the variation being skipped, 0 outside of one
+	lastEnd int // End of the last token of the word being written, -1 to start a new word
+}
+
+// Helper to read the next token, failing on text the lexer cannot read
+func (st *stripper) next() (Token, error) {
+	tok := st.lexer.NextToken()
+	return tok, tok.Error
+}
+
+func (st *stripper) run() error {
+	st.lastEnd = -1
+	for {
+		tok, err := st.next()
+		if err != nil {
+			return err
+		}
+		switch tok.Type {
+		case EOF:
+			return nil
+		case TAG_START:
+			err = st.tag()
+		case COMMENT_START:
+			err = st.comment()
+		case VARIATION_START:
+			st.variationStart()
+		case VARIATION_END:
+			st.variationEnd()
+		default:
+			st.word(tok)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Helper to read a tag, keeping it unless stripped
+func (st *stripper) tag() error {
+	var key, value string
+	for {
+		tok, err := st.next()
+		if err != nil {
+			return err
+		}
+		switch tok.Type {
+		case TAG_KEY:
+			key = tok.Value
+		case TAG_VALUE:
+			value = tok.Value
+		case TAG_END:
+			if value, ok := st.tagValue(key, value); ok {
+				st.tags = append(st.tags, Tag{Key: key, Value: value})
+			}
+			return nil
+		default:
+			return ErrUnexpectedToken(tok.Pos)
+		}
+	}
+}
+
+// tagValue returns the stripped value of a tag, or false to drop it
+func (st *stripper) tagValue(key, value string) (string, bool) {
+	opts := st.opts
+	switch {
+	case opts.Names && (key == "White" || key == "Black"):
+		return st.names.Name(value), true
+	case opts.Site && key == "Site":
+		return "?", true
+	case opts.Date && key == "Date":
+		return "????.??.??", true
+	case opts.Names && slices.Contains(nameTags, key),
+		opts.Ratings && slices.Contains(ratingTags, key),
+		opts.Date && slices.Contains(dateTags, key),
+		slices.Contains(opts.Tags, key):
+		return "", false
+	}
+	return value, true
+}
+
+// Helper to read a comment with its commands, keeping what is not stripped
+func (st *stripper) comment() error {
+	var text []string
+	var commands []Command
+	for {
+		tok, err := st.next()
+		if err != nil {
+			return err
+		}
+		switch tok.Type {
+		case COMMENT:
+			if !st.opts.Comments {
+				text = append(text, tok.Value)
+			}
+		case COMMAND_START:
+			cmd, err := st.command()
+			if err != nil {
+				return err
+			}
+			if !st.opts.Clocks || !slices.Contains(clockNames, cmd.Name) {
+				if !st.opts.Evals || cmd.Name != "eval" {
+					commands = append(commands, cmd)
+				}
+			}
+		case COMMENT_END:
+			if st.depth == 0 && (len(text) > 0 || len(commands) > 0) {
+				st.mw.comments([]string{strings.Join(text, " ")}, commands)
+			}
+			st.lastEnd = -1
+			return nil
+		case EOF:
+			return ErrUnterminatedComment(tok.Pos)
+		default:
+			return ErrUnexpectedToken(tok.Pos)
+		}
+	}
+}
+
+// Helper to read a command after its [%
+func (st *stripper) command() (Command, error) {
+	var cmd Command
+	for {
+		tok, err := st.next()
+		if err != nil {
+			return cmd, err
+		}
+		switch tok.Type {
+		case COMMAND_NAME:
+			cmd.Name = tok.Value
+		case COMMAND_PARAM:
+			cmd.Params = append(cmd.Params, tok.Value)
+		case COMMAND_END:
+			return cmd, nil
+		default:
+			return cmd, ErrInvalidCommand(tok.Pos)
+		}
+	}
+}
+
+func (st *stripper) variationStart() {
+	st.lastEnd = -1
+	if st.opts.Variations || st.depth > 0 {
+		st.depth++
+		return
+	}
+	st.mw.glue = "("
+}
+
+func (st *stripper) variationEnd() {
+	st.lastEnd = -1
+	if st.depth > 0 {
+		st.depth--
+		return
+	}
+	if st.mw.glue != "" {
+		st.mw.add(")") // An empty variation
+		return
+	}
+	st.mw.appendLast(")")
+}
+
+// Helper to write a token of the movetext. The tokens of a move, such as
+// N, x and f3, are joined back into one word, as is a move number with its
+// dots; the move after the dots and NAGs start new words.
+func (st *stripper) word(tok Token) {
+	if st.depth > 0 {
+		return
+	}
+	if tok.Pos == st.lastEnd && tok.Type != NAG {
+		st.mw.appendLast(tok.Value)
+	} else {
+		st.mw.add(tok.Value)
+	}
+	st.lastEnd = tok.End
+	if tok.Type == DOT || tok.Type == ELLIPSIS {
+		st.lastEnd = -1
+	}
+}
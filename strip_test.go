@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const stripGame = `[Event "Club Championship"]
+[Site "Berlin GER"]
+[Date "2023.01.02"]
+[Round "1"]
+[White "Carlsen, Magnus"]
+[Black "Nakamura, Hikaru"]
+[Result "1-0"]
+[WhiteElo "2830"]
+[BlackTitle "GM"]
+[UTCTime "12:00:00"]
+[ECO "C20"]
+
+1.e4 {A good start [%clk 0:05:00] [%eval 0.30]} e5$1 ({Sharper} 1... c5 (1... e6) 2.Nf3) 2. Nf3!? ( ) 2... Nc6 1-0
+`
+
+func TestStripGame(t *testing.T) {
+	tests := []struct {
+		name string
+		opts StripOptions
+		want string
+	}{
+		{"defaults", DefaultStripOptions, `[Event "Club Championship"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "1"]
+[White "Player 1"]
+[Black "Player 2"]
+[Result "1-0"]
+[ECO "C20"]
+
+1. e4 {[%clk 0:05:00] [%eval 0.30] A good start} e5 $1 ({Sharper} 1... c5 (1...
+e6) 2. Nf3) 2. Nf3!? () 2... Nc6 1-0
+
+`},
+		{"annotations only", StripOptions{Comments: true, Variations: true, Clocks: true, Tags: []string{"ECO", "UTCTime"}}, `[Event "Club Championship"]
+[Site "Berlin GER"]
+[Date "2023.01.02"]
+[Round "1"]
+[White "Carlsen, Magnus"]
+[Black "Nakamura, Hikaru"]
+[Result "1-0"]
+[WhiteElo "2830"]
+[BlackTitle "GM"]
+
+1. e4 {[%eval 0.30]} e5 $1 2. Nf3!? 2... Nc6 1-0
+
+`},
+		{"everything", StripOptions{Names: true, Ratings: true, Site: true, Date: true, Comments: true, Variations: true, Clocks: true, Evals: true}, `[Event "Club Championship"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "1"]
+[White "Player 1"]
+[Black "Player 2"]
+[Result "1-0"]
+[ECO "C20"]
+
+1. e4 e5 $1 2. Nf3!? 2... Nc6 1-0
+
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StripGame(&Game{Raw: stripGame}, tt.opts, &Pseudonyms{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+			if _, err := ParseGame(&Game{Raw: got}); err != nil {
+				t.Errorf("Stripped game does not parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestStripGameTags(t *testing.T) {
+	// Network addresses of the players and the times of the game go too
+	raw := "[Event \"E\"]\n[WhiteNA \"w@example.com\"]\n[BlackNA \"b@example.com\"]\n[EndDate \"2023.01.03\"]\n" +
+		"[StartTime \"12:00:00\"]\n[EndTime \"13:00:00\"]\n\n1. e4 *"
+	got, err := StripGame(&Game{Raw: raw}, DefaultStripOptions, &Pseudonyms{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"WhiteNA", "BlackNA", "EndDate", "StartTime", "EndTime"} {
+		if strings.Contains(got, "["+key+" ") {
+			t.Errorf("Expected %s to be dropped, got:\n%s", key, got)
+		}
+	}
+}
+
+func TestStripGameErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"unterminated comment", "1. e4 {Carlsen's secret 1-0", ErrUnterminatedComment(0)},
+		{"unreadable text", "1. e4 Carlsen e5 1-0", ErrInvalidPiece(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := StripGame(&Game{Raw: tt.input}, DefaultStripOptions, &Pseudonyms{}); !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestPseudonyms(t *testing.T) {
+	var p Pseudonyms
+	got := []string{p.Name("Carlsen, Magnus"), p.Name("Nakamura, Hikaru"), p.Name(" Carlsen, Magnus"), p.Name("?"), p.Name("Caruana, Fabiano")}
+	want := []string{"Player 1", "Player 2", "Player 1", "?", "Player 3"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestStripCommand(t *testing.T) {
+	input := "[Event \"E\"]\n[White \"A\"]\n[Black \"B\"]\n\n1. e4 1-0\n\n[Event \"E\"]\n[White \"B\"]\n[Black \"C\"]\n\n1. d4 0-1\n\n[Event \"E\"]\n[White \"A\"]\n\n1. e4 {open 1-0\n"
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"strip", "-comments"}); err != errFailed {
+		t.Errorf("Expected the unterminated comment to fail the command, got %v", err)
+	}
+	want := "[Event \"E\"]\n[White \"Player 1\"]\n[Black \"Player 2\"]\n\n1. e4 1-0\n\n[Event \"E\"]\n[White \"Player 2\"]\n[Black \"Player 3\"]\n\n1. d4 0-1\n\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "<stdin>:16:8: error: unterminated comment\n") {
+		t.Errorf("Expected the third game to be reported, got %q", stderr.String())
+	}
+	// Without Event tags the scanner delivers the games together
+	stdout.Reset()
+	e = &env{stdin: strings.NewReader("[White \"A\"]\n\n1. e4 e5 1-0\n\n[White \"B\"]\n\n1. d4 d5 0-1\n"), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"strip"}); err != nil {
+		t.Fatal(err)
+	}
+	want = "[White \"Player 1\"]\n\n1. e4 e5 1-0\n\n[White \"Player 2\"]\n\n1. d4 d5 0-1\n\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is a PGN date (YYYY.MM.DD) where any component may be unknown ("??"),
+// in which case it is 0
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// ParseDate parses a PGN date such as 2023.12.06, 2023.??.06 or ????.??.??
+func ParseDate(s string) (Date, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 || len(parts[0]) != 4 || len(parts[1]) != 2 || len(parts[2]) != 2 {
+		return Date{}, ErrInvalidTagValue(0)
+	}
+
+	var values [3]int
+	limits := [3]int{9999, 12, 31}
+	for i, part := range parts {
+		if strings.Trim(part, "?") == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > limits[i] || strings.Trim(part, "0123456789") != "" {
+			return Date{}, ErrInvalidTagValue(0)
+		}
+		values[i] = n
+	}
+	return Date{Year: values[0], Month: values[1], Day: values[2]}, nil
+}
+
+// IsComplete reports whether every component of the date is known
+func (d Date) IsComplete() bool {
+	return d.Year != 0 && d.Month != 0 && d.Day != 0
+}
+
+// Time returns the date as a time at midnight UTC, if it is complete
+func (d Date) Time() (time.Time, bool) {
+	if !d.IsComplete() {
+		return time.Time{}, false
+	}
+	return time.Date(d.Year, time.Month(d.Month), d.Day, 0, 0, 0, 0, time.UTC), true
+}
+
+func (d Date) String() string {
+	format := func(n, width int) string {
+		if n == 0 {
+			return strings.Repeat("?", width)
+		}
+		s := strconv.Itoa(n)
+		return strings.Repeat("0", width-len(s)) + s
+	}
+	return format(d.Year, 4) + "." + format(d.Month, 2) + "." + format(d.Day, 2)
+}
+
+// Round is a PGN round such as 3 or 1.2 (a game in a match of a round)
+type Round struct {
+	Parts   []int // Hierarchical round numbers, empty if unknown or not applicable
+	Unknown bool  // "?"
+	None    bool  // "-", the round is not applicable
+}
+
+// ParseRound parses a PGN round such as 3, 1.2, ? or -
+func ParseRound(s string) (Round, error) {
+	switch s {
+	case "?":
+		return Round{Unknown: true}, nil
+	case "-":
+		return Round{None: true}, nil
+	}
+
+	var r Round
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.Trim(part, "0123456789") != "" {
+			return Round{}, ErrInvalidTagValue(0)
+		}
+		r.Parts = append(r.Parts, n)
+	}
+	return r, nil
+}
+
+func (r Round) String() string {
+	switch {
+	case r.Unknown:
+		return "?"
+	case r.None:
+		return "-"
+	}
+	parts := make([]string, len(r.Parts))
+	for i, n := range r.Parts {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// TimeControlKind is the kind of a time control period
+type TimeControlKind int
+
+const (
+	MovesInTime TimeControlKind = iota // 40/7200, a number of moves in a given time
+	SuddenDeath                        // 3600, the rest of the game in a given time
+	Hourglass                          // *60, hourglass with a given time
+)
+
+// TimeControlPeriod is one period of a time control
+type TimeControlPeriod struct {
+	Kind      TimeControlKind
+	Moves     int // Only for MovesInTime
+	Time      time.Duration
+	Increment time.Duration // Added after each move
+}
+
+func (p TimeControlPeriod) String() string {
+	seconds := strconv.Itoa(int(p.Time / time.Second))
+	var s string
+	switch p.Kind {
+	case MovesInTime:
+		s = strconv.Itoa(p.Moves) + "/" + seconds
+	case Hourglass:
+		s = "*" + seconds
+	default:
+		s = seconds
+	}
+	if p.Increment > 0 {
+		s += "+" + strconv.Itoa(int(p.Increment/time.Second))
+	}
+	return s
+}
+
+// TimeControl is a PGN time control made of periods played in order
+type TimeControl struct {
+	Periods []TimeControlPeriod
+	Unknown bool // "?"
+	None    bool // "-", no time control
+}
+
+// ParseTimeControl parses a PGN time control such as 40/7200:3600+30, 300+2,
+// *180, ? or -. Fields are separated by colons.
+func ParseTimeControl(s string) (TimeControl, error) {
+	switch s {
+	case "?":
+		return TimeControl{Unknown: true}, nil
+	case "-":
+		return TimeControl{None: true}, nil
+	}
+
+	var tc TimeControl
+	for _, field := range strings.Split(s, ":") {
+		period, err := parseTimeControlPeriod(field)
+		if err != nil {
+			return TimeControl{}, err
+		}
+		tc.Periods = append(tc.Periods, period)
+	}
+	return tc, nil
+}
+
+func parseTimeControlPeriod(field string) (TimeControlPeriod, error) {
+	var p TimeControlPeriod
+	var err error
+
+	if rest, ok := strings.CutPrefix(field, "*"); ok {
+		p.Kind = Hourglass
+		p.Time, err = parseSeconds(rest)
+		return p, err
+	}
+
+	field, increment, hasIncrement := strings.Cut(field, "+")
+	if hasIncrement {
+		if p.Increment, err = parseSeconds(increment); err != nil {
+			return p, err
+		}
+	}
+
+	p.Kind = SuddenDeath
+	if moves, seconds, ok := strings.Cut(field, "/"); ok {
+		p.Kind = MovesInTime
+		if p.Moves, err = parseCount(moves); err != nil || p.Moves == 0 {
+			return p, ErrInvalidTagValue(0)
+		}
+		field = seconds
+	}
+	p.Time, err = parseSeconds(field)
+	return p, err
+}
+
+func (tc TimeControl) String() string {
+	switch {
+	case tc.Unknown:
+		return "?"
+	case tc.None:
+		return "-"
+	}
+	fields := make([]string, len(tc.Periods))
+	for i, p := range tc.Periods {
+		fields[i] = p.String()
+	}
+	return strings.Join(fields, ":")
+}
+
+// Helper to parse a non-negative integer made of digits only
+func parseCount(s string) (int, error) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, ErrInvalidTagValue(0)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ErrInvalidTagValue(0)
+	}
+	return n, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	n, err := parseCount(s)
+	return time.Duration(n) * time.Second, err
+}
+
+// ParseElo parses a rating tag such as WhiteElo
+func ParseElo(s string) (int, error) {
+	return parseCount(s)
+}
+
+// ParseECO validates an ECO code (A00 to E99), returning it unchanged
+func ParseECO(s string) (string, error) {
+	if len(s) != 3 || s[0] < 'A' || s[0] > 'E' || !isDigit(s[1]) || !isDigit(s[2]) {
+		return "", ErrInvalidTagValue(0)
+	}
+	return s, nil
+}
+
+// ParseUTC combines the UTCDate and UTCTime tags (2023.12.06, 18:30:00) into a time
+func ParseUTC(date, clock string) (time.Time, error) {
+	t, err := time.Parse("2006.01.02 15:04:05", date+" "+clock)
+	if err != nil {
+		return time.Time{}, ErrInvalidTagValue(0)
+	}
+	return t, nil
+}
+
+// Helper to look up a tag and parse its value
+func parseTag[T any](g *ParsedGame, key string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	value, ok := g.Tag(key)
+	if !ok {
+		return zero, ErrMissingTag(0)
+	}
+	return parse(value)
+}
+
+// Date parses the Date tag
+func (g *ParsedGame) Date() (Date, error) {
+	return parseTag(g, "Date", ParseDate)
+}
+
+// Round parses the Round tag
+func (g *ParsedGame) Round() (Round, error) {
+	return parseTag(g, "Round", ParseRound)
+}
+
+// TimeControl parses the TimeControl tag
+func (g *ParsedGame) TimeControl() (TimeControl, error) {
+	return parseTag(g, "TimeControl", ParseTimeControl)
+}
+
+// WhiteElo parses the WhiteElo tag
+func (g *ParsedGame) WhiteElo() (int, error) {
+	return parseTag(g, "WhiteElo", ParseElo)
+}
+
+// BlackElo parses the BlackElo tag
+func (g *ParsedGame) BlackElo() (int, error) {
+	return parseTag(g, "BlackElo", ParseElo)
+}
+
+// ECO parses the ECO tag
+func (g *ParsedGame) ECO() (string, error) {
+	return parseTag(g, "ECO", ParseECO)
+}
+
+// UTC parses the UTCDate and UTCTime tags
+func (g *ParsedGame) UTC() (time.Time, error) {
+	date, ok := g.Tag("UTCDate")
+	if !ok {
+		return time.Time{}, ErrMissingTag(0)
+	}
+	return parseTag(g, "UTCTime", func(clock string) (time.Time, error) {
+		return ParseUTC(date, clock)
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Date
+	}{
+		{"2023.12.06", Date{Year: 2023, Month: 12, Day: 6}},
+		{"2023.??.06", Date{Year: 2023, Day: 6}},
+		{"????.??.??", Date{}},
+		{"1851.07.??", Date{Year: 1851, Month: 7}},
+	}
+	for _, tt := range tests {
+		date, err := ParseDate(tt.input)
+		if err != nil || date != tt.expected {
+			t.Errorf("%q - Expected %+v, got %+v (%v)", tt.input, tt.expected, date, err)
+		}
+		if date.String() != tt.input {
+			t.Errorf("Expected %q, got %q", tt.input, date.String())
+		}
+	}
+
+	for _, input := range []string{"", "2023", "2023.13.01", "2023.12.32", "2023.1.06", "2023.-1.06", "20a3.12.06", "2023.00.06"} {
+		if _, err := ParseDate(input); !errors.Is(err, ErrInvalidTagValue(0)) {
+			t.Errorf("Expected error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestParseRound(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Round
+	}{
+		{"3", Round{Parts: []int{3}}},
+		{"1.2", Round{Parts: []int{1, 2}}},
+		{"?", Round{Unknown: true}},
+		{"-", Round{None: true}},
+	}
+	for _, tt := range tests {
+		round, err := ParseRound(tt.input)
+		if err != nil || !reflect.DeepEqual(round, tt.expected) || round.String() != tt.input {
+			t.Errorf("%q - Expected %+v, got %+v (%v)", tt.input, tt.expected, round, err)
+		}
+	}
+
+	for _, input := range []string{"", "1.", "a", "1.-2", "+1"} {
+		if _, err := ParseRound(input); !errors.Is(err, ErrInvalidTagValue(0)) {
+			t.Errorf("Expected error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected TimeControl
+	}{
+		{"40/7200:3600+30", TimeControl{Periods: []TimeControlPeriod{
+			{Kind: MovesInTime, Moves: 40, Time: 2 * time.Hour},
+			{Kind: SuddenDeath, Time: time.Hour, Increment: 30 * time.Second},
+		}}},
+		{"40/9000:300", TimeControl{Periods: []TimeControlPeriod{
+			{Kind: MovesInTime, Moves: 40, Time: 9000 * time.Second},
+			{Kind: SuddenDeath, Time: 5 * time.Minute},
+		}}},
+		{"300+2", TimeControl{Periods: []TimeControlPeriod{{Kind: SuddenDeath, Time: 5 * time.Minute, Increment: 2 * time.Second}}}},
+		{"4500", TimeControl{Periods: []TimeControlPeriod{{Kind: SuddenDeath, Time: 4500 * time.Second}}}},
+		{"*180", TimeControl{Periods: []TimeControlPeriod{{Kind: Hourglass, Time: 3 * time.Minute}}}},
+		{"?", TimeControl{Unknown: true}},
+		{"-", TimeControl{None: true}},
+	}
+	for _, tt := range tests {
+		tc, err := ParseTimeControl(tt.input)
+		if err != nil || !reflect.DeepEqual(tc, tt.expected) {
+			t.Errorf("%q - Expected %+v, got %+v (%v)", tt.input, tt.expected, tc, err)
+		}
+		if tc.String() != tt.input {
+			t.Errorf("Expected %q, got %q", tt.input, tc.String())
+		}
+	}
+
+	for _, input := range []string{"", "40/", "/300", "0/300", "300+", "*", "5m", "40/7200::300", "300+2+2"} {
+		if _, err := ParseTimeControl(input); !errors.Is(err, ErrInvalidTagValue(0)) {
+			t.Errorf("Expected error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestParsedGameTags(t *testing.T) {
+	g := loadFixtureGame(t, "annotated_game.pgn")
+
+	if date, err := g.Date(); err != nil || date != (Date{Year: 2023, Day: 6}) {
+		t.Errorf("Unexpected date %+v (%v)", date, err)
+	}
+	if round, err := g.Round(); err != nil || round.String() != "1.2" {
+		t.Errorf("Unexpected round %+v (%v)", round, err)
+	}
+	if tc, err := g.TimeControl(); err != nil || len(tc.Periods) != 2 {
+		t.Errorf("Unexpected time control %+v (%v)", tc, err)
+	}
+	if elo, err := g.WhiteElo(); err != nil || elo != 2750 {
+		t.Errorf("Unexpected WhiteElo %d (%v)", elo, err)
+	}
+	if elo, err := g.BlackElo(); err != nil || elo != 2680 {
+		t.Errorf("Unexpected BlackElo %d (%v)", elo, err)
+	}
+	if _, err := g.ECO(); !errors.Is(err, ErrMissingTag(0)) {
+		t.Errorf("Expected missing ECO, got %v", err)
+	}
+
+	g.SetTag("ECO", "C54")
+	g.SetTag("WhiteElo", "-")
+	g.SetTag("UTCDate", "2023.12.06")
+	g.SetTag("UTCTime", "18:30:05")
+	if eco, err := g.ECO(); err != nil || eco != "C54" {
+		t.Errorf("Unexpected ECO %q (%v)", eco, err)
+	}
+	if _, err := g.WhiteElo(); !errors.Is(err, ErrInvalidTagValue(0)) {
+		t.Errorf("Expected invalid WhiteElo, got %v", err)
+	}
+	if utc, err := g.UTC(); err != nil || !utc.Equal(time.Date(2023, 12, 6, 18, 30, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected UTC time %v (%v)", utc, err)
+	}
+	for _, eco := range []string{"F00", "A1", "a00", "A0x"} {
+		if _, err := ParseECO(eco); err == nil {
+			t.Errorf("Expected error for ECO %q", eco)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEngineTimeout is returned when an engine does not reply in time
+var ErrEngineTimeout = errors.New("engine did not reply in time")
+
+// ErrEngineExited is returned when an engine stops while a reply is awaited
+var ErrEngineExited = errors.New("engine exited")
+
+// Time to wait for replies other than search results, unless set otherwise
+const defaultEngineTimeout = 10 * time.Second
+
+// EngineOption is an option an engine declared during the handshake
+type EngineOption struct {
+	Name    string
+	Type    string // check, spin, combo, button or string
+	Default string
+}
+
+// Engine is a chess engine process speaking the UCI protocol
+type Engine struct {
+	Name    string // From "id name" during the handshake
+	Author  string
+	Options map[string]EngineOption // By lower case name, as UCI names are case insensitive
+	Timeout time.Duration           // Maximum wait for the handshake and other replies
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string   // Lines of output, closed when the engine exits
+	done    chan struct{} // Closed when the lines are no longer read
+	stop    sync.Once
+	multiPV int
+}
+
+// NewEngine prepares an engine process, which Start launches
+func NewEngine(path string, args ...string) *Engine {
+	return &Engine{Timeout: defaultEngineTimeout, cmd: exec.Command(path, args...), multiPV: 1}
+}
+
+// Start launches the engine and performs the UCI handshake, collecting its
+// name and options
+func (e *Engine) Start() error {
+	stdin, err := e.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := e.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := e.cmd.Start(); err != nil {
+		return err
+	}
+	e.stdin = stdin
+	e.lines = make(chan string, 64)
+	e.done = make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case e.lines <- scanner.Text():
+			case <-e.done:
+				// Discard the output so that the engine is not blocked writing it
+			}
+		}
+		close(e.lines)
+	}()
+
+	e.Options = map[string]EngineOption{}
+	if err := e.send("uci"); err != nil {
+		e.kill()
+		return err
+	}
+	_, err = e.readUntil("uciok", e.timer(e.Timeout), func(fields []string, line string) {
+		switch {
+		case len(fields) > 2 && fields[0] == "id" && fields[1] == "name":
+			e.Name = strings.TrimSpace(strings.TrimPrefix(line, "id name"))
+		case len(fields) > 2 && fields[0] == "id" && fields[1] == "author":
+			e.Author = strings.TrimSpace(strings.TrimPrefix(line, "id author"))
+		case fields[0] == "option":
+			o := parseEngineOption(fields[1:])
+			e.Options[strings.ToLower(o.Name)] = o
+		}
+	})
+	if err == nil {
+		err = e.IsReady()
+	}
+	if err != nil {
+		e.kill()
+		return fmt.Errorf("engine handshake: %w", err)
+	}
+	return nil
+}
+
+// Helper to parse the words after "option", such as
+// name Skill Level type spin default 20 min 0 max 20
+func parseEngineOption(words []string) EngineOption {
+	var o EngineOption
+	var field *string
+	for _, word := range words {
+		switch word {
+		case "name":
+			field = &o.Name
+		case "type":
+			field = &o.Type
+		case "default":
+			field = &o.Default
+		case "min", "max", "var":
+			field = nil
+		default:
+			if field != nil {
+				*field = strings.TrimSpace(*field + " " + word)
+			}
+		}
+	}
+	return o
+}
+
+// SetOption sets an engine option, such as Hash or Threads
+func (e *Engine) SetOption(name, value string) error {
+	line := "setoption name " + name
+	if value != "" {
+		line += " value " + value
+	}
+	if err := e.send(line); err != nil {
+		return err
+	}
+	return e.IsReady()
+}
+
+// IsReady waits for the engine to process the commands sent so far
+func (e *Engine) IsReady() error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	_, err := e.readUntil("readyok", e.timer(e.Timeout), nil)
+	return err
+}
+
+// NewGame tells the engine that the next positions are from another game
+func (e *Engine) NewGame() error {
+	if err := e.send("ucinewgame"); err != nil {
+		return err
+	}
+	return e.IsReady()
+}
+
+// SearchLimit bounds an engine search. At least one of Depth and MoveTime
+// should be set.
+type SearchLimit struct {
+	Depth    int
+	MoveTime time.Duration
+	MultiPV  int           // Number of best lines, 1 when unset
+	Timeout  time.Duration // The search is stopped after this long, MoveTime plus the engine's Timeout when unset
+}
+
+// EngineLine is one of the best lines found by a search
+type EngineLine struct {
+	Eval  Eval // From White's point of view, with the search depth
+	Moves []Move
+}
+
+// Analysis is the result of a search
+type Analysis struct {
+	Lines    []EngineLine // Best first
+	BestMove Move
+	HasMove  bool // False when the position has no legal move
+}
+
+// Analyze searches a position, returning the best lines by their last
+// report. When the limit's Timeout expires the search is stopped and the
+// lines found so far are returned, so that a hung engine never blocks.
+func (e *Engine) Analyze(p *Position, limit SearchLimit) (*Analysis, error) {
+	multiPV := max(limit.MultiPV, 1)
+	if multiPV != e.multiPV {
+		if err := e.SetOption("MultiPV", strconv.Itoa(multiPV)); err != nil {
+			return nil, err
+		}
+		e.multiPV = multiPV
+	}
+
+	goCommand := "go"
+	if limit.Depth > 0 {
+		goCommand += " depth " + strconv.Itoa(limit.Depth)
+	}
+	if limit.MoveTime > 0 {
+		goCommand += " movetime " + strconv.FormatInt(limit.MoveTime.Milliseconds(), 10)
+	}
+	if err := e.send("position fen " + p.FEN()); err != nil {
+		return nil, err
+	}
+	if err := e.send(goCommand); err != nil {
+		return nil, err
+	}
+
+	lines := make([]EngineLine, multiPV)
+	found := make([]bool, multiPV)
+	info := func(fields []string, _ string) {
+		if fields[0] != "info" {
+			return
+		}
+		if rank, line, ok := parseInfo(p, fields[1:]); ok && rank <= multiPV {
+			lines[rank-1], found[rank-1] = line, true
+		}
+	}
+	timeout := limit.Timeout
+	if timeout <= 0 {
+		timeout = limit.MoveTime + e.Timeout
+	}
+	bestMove, err := e.readUntil("bestmove", e.timer(timeout), info)
+	if err == ErrEngineTimeout {
+		// Out of time: ask for the best move so far
+		if err := e.send("stop"); err != nil {
+			return nil, err
+		}
+		bestMove, err = e.readUntil("bestmove", e.timer(e.Timeout), info)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Analysis{}
+	for i, line := range lines {
+		if found[i] {
+			a.Lines = append(a.Lines, line)
+		}
+	}
+	if m, err := p.ParseUCI(bestMove); err == nil {
+		a.BestMove, a.HasMove = m, true
+	}
+	return a, nil
+}
+
+// AnalyzeGame searches every position of the main line, storing each
+// evaluation in an %eval command of the move that led to it. Where the engine
+// prefers other moves than the one played, its best lines are added as
+// variations of up to variationPlies moves; 0 adds none.
+func (e *Engine) AnalyzeGame(g *ParsedGame, limit SearchLimit, variationPlies int) error {
+	if err := e.NewGame(); err != nil {
+		return err
+	}
+	for node := g.Root; node != nil; node = node.Next() {
+		if len(node.Position.LegalMoves()) == 0 {
+			break // Checkmate or stalemate
+		}
+		a, err := e.Analyze(node.Position, limit)
+		if err != nil {
+			return err
+		}
+		if len(a.Lines) == 0 {
+			continue
+		}
+		if node != g.Root {
+			node.SetCommand(Command{Name: "eval", Params: strings.Split(a.Lines[0].Eval.String(), ",")})
+		}
+		if variationPlies == 0 || node.Next() == nil {
+			continue
+		}
+		for _, line := range a.Lines {
+			if len(line.Moves) > 0 && !hasChild(node, line.Moves[0]) {
+				addLine(node, line.Moves[:min(len(line.Moves), variationPlies)])
+			}
+		}
+	}
+	return nil
+}
+
+// Helper to tell whether a move already continues a node
+func hasChild(node *MoveNode, m Move) bool {
+	for _, child := range node.Children {
+		if child.Move == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper to add a line of legal moves from a node
+func addLine(node *MoveNode, moves []Move) {
+	for _, m := range moves {
+		node = node.AddChild(m)
+	}
+}
+
+// Helper to parse the words after "info", returning the multipv rank and the
+// line. Reports without a score or with a bound instead of an exact score
+// are skipped.
+func parseInfo(p *Position, words []string) (int, EngineLine, bool) {
+	rank := 1
+	var line EngineLine
+	hasScore := false
+	for i := 0; i < len(words); i++ {
+		next := func() int {
+			if i+1 >= len(words) {
+				return 0
+			}
+			i++
+			n, _ := strconv.Atoi(words[i])
+			return n
+		}
+		switch words[i] {
+		case "depth":
+			line.Eval.Depth = next()
+		case "multipv":
+			rank = next()
+		case "score":
+			if i+1 < len(words) && words[i+1] == "cp" {
+				i++
+				line.Eval.Centipawns, hasScore = next(), true
+			} else if i+1 < len(words) && words[i+1] == "mate" {
+				i++
+				line.Eval.Mate, hasScore = next(), true
+			}
+			if i+1 < len(words) && (words[i+1] == "lowerbound" || words[i+1] == "upperbound") {
+				return 0, line, false
+			}
+		case "pv":
+			position := p
+			for _, word := range words[i+1:] {
+				m, err := position.ParseUCI(word)
+				if err != nil {
+					break
+				}
+				line.Moves = append(line.Moves, m)
+				position = position.Play(m)
+			}
+			i = len(words)
+		}
+	}
+	if !hasScore || rank < 1 {
+		return 0, line, false
+	}
+	// Scores are from the point of view of the side to move
+	if p.Turn() == Black {
+		line.Eval.Centipawns, line.Eval.Mate = -line.Eval.Centipawns, -line.Eval.Mate
+	}
+	return rank, line, true
+}
+
+// Close asks the engine to quit, killing it if it does not within Timeout
+func (e *Engine) Close() error {
+	e.stopReading()
+	e.send("quit")
+	e.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- e.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-e.timer(e.Timeout):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// Helper to stop an engine that failed to start properly
+func (e *Engine) kill() {
+	e.stopReading()
+	e.cmd.Process.Kill()
+	e.cmd.Wait()
+}
+
+// Helper to release the goroutine reading the output once replies are no
+// longer awaited
+func (e *Engine) stopReading() {
+	e.stop.Do(func() { close(e.done) })
+}
+
+func (e *Engine) send(line string) error {
+	_, err := io.WriteString(e.stdin, line+"\n")
+	return err
+}
+
+// Helper to create a channel firing after d, or never when d is 0
+func (e *Engine) timer(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return time.After(d)
+}
+
+// readUntil reads lines until one starting with word, passing the others to
+// fn, and returns the word following it, if any
+func (e *Engine) readUntil(word string, deadline <-chan time.Time, fn func(fields []string, line string)) (string, error) {
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", ErrEngineExited
+			}
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 0:
+			case fields[0] == word:
+				if len(fields) > 1 {
+					return fields[1], nil
+				}
+				return "", nil
+			case fn != nil:
+				fn(fields, line)
+			}
+		case <-deadline:
+			return "", ErrEngineTimeout
+		}
+	}
+}
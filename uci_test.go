@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The test binary doubles as a fake UCI engine when PGN_FAKE_ENGINE is set
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("PGN_FAKE_ENGINE"); mode != "" {
+		fakeEngine(mode, os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeEngine answers UCI commands. In its searches the legal moves are
+// ranked alphabetically, scoring 50, 40, 30... centipawns, except that a
+// mating move comes first as mate in 1. With mode "slow" searches only end
+// on stop, with mode "flood" they first print thousands of lines, and with
+// mode "mute" it never replies.
+func fakeEngine(mode string, r io.Reader, w io.Writer) {
+	if mode == "mute" {
+		io.Copy(io.Discard, r)
+		return
+	}
+	position := NewPosition()
+	multiPV := 1
+	var best string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(w, "id name Fake Engine 1.0")
+			fmt.Fprintln(w, "id author The Tests")
+			fmt.Fprintln(w, "option name MultiPV type spin default 1 min 1 max 500")
+			fmt.Fprintln(w, "option name Skill Level type spin default 20 min 0 max 20")
+			fmt.Fprintln(w, "uciok")
+		case "isready":
+			fmt.Fprintln(w, "readyok")
+		case "setoption":
+			if len(fields) == 5 && fields[2] == "MultiPV" {
+				multiPV, _ = strconv.Atoi(fields[4])
+			}
+		case "position":
+			fen, _, _ := strings.Cut(strings.TrimPrefix(scanner.Text(), "position fen "), " moves")
+			position, _ = ParseFEN(fen)
+		case "go":
+			if mode == "flood" {
+				io.WriteString(w, strings.Repeat("info string flood\n", 10000))
+			}
+			moves := sortedMoves(position)
+			for i, m := range moves {
+				if position.Play(m).IsCheckmate() {
+					moves = append([]Move{m}, slices.Delete(moves, i, i+1)...)
+					break
+				}
+			}
+			for i, m := range moves[:min(multiPV, len(moves))] {
+				score := "cp " + strconv.Itoa(50-10*i)
+				if position.Play(m).IsCheckmate() {
+					score = "mate 1"
+				}
+				pv := m.UCI()
+				if replies := sortedMoves(position.Play(m)); len(replies) > 0 {
+					pv += " " + replies[0].UCI()
+				}
+				fmt.Fprintf(w, "info depth 3 seldepth 4 multipv %d score %s upperbound nodes 10 pv %s\n", i+1, score, pv)
+				fmt.Fprintf(w, "info depth 5 seldepth 6 multipv %d score %s nodes 100 pv %s\n", i+1, score, pv)
+			}
+			best = "(none)"
+			if len(moves) > 0 {
+				best = moves[0].UCI()
+			}
+			if mode != "slow" {
+				fmt.Fprintln(w, "bestmove", best)
+			}
+		case "stop":
+			fmt.Fprintln(w, "bestmove", best)
+		case "quit":
+			return
+		}
+	}
+}
+
+// Helper to list the legal moves of a position in UCI order
+func sortedMoves(p *Position) []Move {
+	moves := p.LegalMoves()
+	slices.SortFunc(moves, func(a, b Move) int {
+		return strings.Compare(a.UCI(), b.UCI())
+	})
+	return moves
+}
+
+// Helper to start the fake engine
+func startFakeEngine(t *testing.T, mode string) *Engine {
+	t.Helper()
+	t.Setenv("PGN_FAKE_ENGINE", mode)
+	engine := NewEngine(os.Args[0])
+	engine.Timeout = time.Second
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+func TestEngineHandshake(t *testing.T) {
+	engine := startFakeEngine(t, "normal")
+	if engine.Name != "Fake Engine 1.0" || engine.Author != "The Tests" {
+		t.Errorf("Unexpected engine %q by %q", engine.Name, engine.Author)
+	}
+	want := EngineOption{Name: "Skill Level", Type: "spin", Default: "20"}
+	if got := engine.Options["skill level"]; got != want {
+		t.Errorf("Expected option %+v, got %+v", want, got)
+	}
+	if err := engine.SetOption("Skill Level", "5"); err != nil {
+		t.Error(err)
+	}
+
+	t.Setenv("PGN_FAKE_ENGINE", "mute")
+	mute := NewEngine(os.Args[0])
+	mute.Timeout = 50 * time.Millisecond
+	if err := mute.Start(); !errors.Is(err, ErrEngineTimeout) {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if err := NewEngine("/nonexistent/engine").Start(); err == nil {
+		t.Errorf("Expected an error starting a missing engine")
+	}
+}
+
+func TestEngineAnalyze(t *testing.T) {
+	engine := startFakeEngine(t, "normal")
+	tests := []struct {
+		name    string
+		fen     string
+		multiPV int
+		want    []string // Eval and moves of each line
+	}{
+		{"white to move", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 2, []string{"0.50,5 a2a3 a7a5", "0.40,5 a2a4 a7a5"}},
+		{"black to move", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", 1, []string{"-0.50,5 a7a5 a2a3"}},
+		{"mate", "7k/Q7/6K1/8/8/8/8/8 w - - 0 1", 1, []string{"#1,5 a7a8"}},
+		{"black mates", "k6r/8/8/8/8/8/PP6/K7 b - - 0 1", 1, []string{"#-1,5 h8h1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := engine.Analyze(p, SearchLimit{Depth: 5, MultiPV: tt.multiPV})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, line := range a.Lines {
+				s := line.Eval.String()
+				for _, m := range line.Moves {
+					s += " " + m.UCI()
+				}
+				got = append(got, s)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if !a.HasMove || a.BestMove != a.Lines[0].Moves[0] {
+				t.Errorf("Unexpected best move %v", a.BestMove)
+			}
+		})
+	}
+}
+
+func TestEngineSearchTimeout(t *testing.T) {
+	engine := startFakeEngine(t, "slow")
+	a, err := engine.Analyze(NewPosition(), SearchLimit{Depth: 40, Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Lines) != 1 || a.BestMove.UCI() != "a2a3" {
+		t.Errorf("Expected the line found before the timeout, got %+v", a)
+	}
+}
+
+func TestEngineDefaultSearchTimeout(t *testing.T) {
+	// Without a Timeout, a search that never ends is stopped after the
+	// engine's Timeout
+	engine := startFakeEngine(t, "slow")
+	engine.Timeout = 20 * time.Millisecond
+	a, err := engine.Analyze(NewPosition(), SearchLimit{Depth: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Lines) != 1 || a.BestMove.UCI() != "a2a3" {
+		t.Errorf("Expected the line found before the timeout, got %+v", a)
+	}
+}
+
+func TestEngineCloseUnreadOutput(t *testing.T) {
+	engine := startFakeEngine(t, "flood")
+	// Output nobody reads must not keep the engine from quitting
+	if err := engine.send("go depth 1"); err != nil {
+		t.Fatal(err)
+	}
+	// Leave time to exit, which takes a second when built with -race
+	engine.Timeout = 5 * time.Second
+	if err := engine.Close(); err != nil {
+		t.Errorf("Expected the engine to quit, got %v", err)
+	}
+}
+
+func TestAnalyzeCommand(t *testing.T) {
+	t.Setenv("PGN_FAKE_ENGINE", "normal")
+	game := "[Event \"E\"]\n\n1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0\n"
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(game), stdout: &stdout, stderr: &stderr}
+	if err := run(e, []string{"analyze", "-engine", os.Args[0], "-depth", "5", "-lines", "1"}); err != nil {
+		t.Fatal(err, stderr.String())
+	}
+	g, err := parseGameSyntax(ParseSyntax(stdout.String()).Games()[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainline := g.Mainline()
+	if len(mainline) != 7 {
+		t.Fatalf("Expected the 7 moves of the game, got:\n%s", stdout.String())
+	}
+	for i, node := range mainline[:6] {
+		if _, ok := node.Eval(); !ok {
+			t.Errorf("Expected an evaluation after ply %d", i+1)
+		}
+	}
+	if _, ok := mainline[6].Eval(); ok {
+		t.Errorf("Expected no evaluation after checkmate")
+	}
+	// The engine prefers a2a3 to 1. e4, and mate to 3... Nf6 4. Qxf7#
+	if len(g.Root.Children) != 2 || g.Root.Children[1].SAN != "a3" || len(g.Root.Children[1].Children) != 0 {
+		t.Errorf("Expected 1. a3 as a one move variation, got:\n%s", stdout.String())
+	}
+	if len(mainline[5].Children) != 1 {
+		t.Errorf("Expected no variation for the mating move, got:\n%s", stdout.String())
+	}
+}
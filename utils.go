@@ -1,5 +1,7 @@
 package main
 
+import "strings"
+
 func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
 }
@@ -12,6 +14,10 @@ func isWhitespace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
+func isTokenBoundary(ch byte) bool {
+	return isWhitespace(ch) || strings.IndexByte("{}()[]$\"", ch) >= 0
+}
+
 func isResult(s string) bool {
 	return s == "1-0" || s == "0-1" || s == "1/2-1/2" || s == "*"
 }
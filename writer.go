@@ -0,0 +1,165 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Maximum line length of movetext in export format
+const exportLineWidth = 80
+
+// WritePGN writes a game in PGN export format: one tag per line, a blank
+// line, then the movetext wrapped at 80 columns and followed by a blank line
+func WritePGN(w io.Writer, g *ParsedGame) error {
+	_, err := io.WriteString(w, g.String())
+	return err
+}
+
+// String returns the game in PGN export format
+func (g *ParsedGame) String() string {
+	var sb strings.Builder
+	for _, tag := range g.Tags {
+		sb.WriteString("[" + tag.Key + " \"" + tagValueEscaper.Replace(tag.Value) + "\"]\n")
+	}
+	if len(g.Tags) > 0 {
+		sb.WriteByte('\n')
+	}
+
+	mw := &movetextWriter{}
+	mw.comments(g.Root.Comments, g.Root.Commands)
+	if next := g.Root.Next(); next != nil {
+		mw.line(next)
+	}
+	mw.add(g.Result)
+
+	sb.WriteString(mw.wrap(exportLineWidth))
+	sb.WriteString("\n\n")
+	return sb.String()
+}
+
+// movetextWriter collects the words of the movetext before wrapping them
+type movetextWriter struct {
+	words []string
+	glue  string // prefix for the next word, such as "("
+}
+
+func (mw *movetextWriter) add(word string) {
+	mw.words = append(mw.words, mw.glue+word)
+	mw.glue = ""
+}
+
+// Helper to append a suffix, such as ")", to the last word
+func (mw *movetextWriter) appendLast(suffix string) {
+	mw.words[len(mw.words)-1] += suffix
+}
+
+// line writes a line of moves starting at node, followed at each move by the
+// variations replacing it
+func (mw *movetextWriter) line(node *MoveNode) {
+	needNumber := true
+	for ; node != nil; node = node.Next() {
+		needNumber = mw.move(node, needNumber)
+
+		parent := node.Parent
+		if parent.Children[0] != node {
+			continue
+		}
+		for _, variation := range parent.Children[1:] {
+			mw.glue = "("
+			mw.line(variation)
+			mw.appendLast(")")
+			needNumber = true
+		}
+	}
+}
+
+// move writes a single move with its annotations and reports whether the next
+// move needs its number repeated
+func (mw *movetextWriter) move(node *MoveNode, needNumber bool) bool {
+	if len(node.StartingComments) > 0 {
+		mw.comments(node.StartingComments, nil)
+		needNumber = true
+	}
+
+	before := node.Parent.Position
+	number := strconv.Itoa(before.fullmove)
+	if before.turn == White {
+		mw.add(number + ".")
+	} else if needNumber {
+		mw.add(number + "...")
+	}
+	mw.add(node.SAN)
+
+	for _, nag := range node.NAGs {
+		mw.add("$" + strconv.Itoa(nag))
+	}
+
+	mw.comments(node.Comments, node.Commands)
+	return len(node.Comments) > 0 || len(node.Commands) > 0
+}
+
+// Helper to write comments, with any commands placed in the first one
+func (mw *movetextWriter) comments(comments []string, commands []Command) {
+	if len(comments) == 0 && len(commands) == 0 {
+		return
+	}
+	if len(comments) == 0 {
+		comments = []string{""}
+	}
+
+	for i, comment := range comments {
+		var words []string
+		if i == 0 {
+			for _, cmd := range commands {
+				words = append(words, strings.Fields(cmd.String())...)
+			}
+		}
+		words = append(words, strings.Fields(comment)...)
+
+		if len(words) == 0 {
+			mw.add("{}")
+			continue
+		}
+		mw.glue += "{" // After any "(" of a variation starting with a comment
+		for _, word := range words {
+			mw.add(word)
+		}
+		mw.appendLast("}")
+	}
+}
+
+// Helper to join words with spaces, breaking lines before they exceed width
+func (mw *movetextWriter) wrap(width int) string {
+	var sb strings.Builder
+	lineLen := 0
+	for _, word := range mw.words {
+		switch {
+		case lineLen == 0:
+		case lineLen+1+len(word) > width:
+			sb.WriteByte('\n')
+			lineLen = 0
+		default:
+			sb.WriteByte(' ')
+			lineLen++
+		}
+		sb.WriteString(word)
+		lineLen += len(word)
+	}
+	return sb.String()
+}
+
+// String returns the command as it is written inside a comment
+func (c Command) String() string {
+	params := make([]string, len(c.Params))
+	for i, param := range c.Params {
+		if strings.ContainsAny(param, ",]}") || strings.TrimSpace(param) != param {
+			param = `"` + param + `"`
+		}
+		params[i] = param
+	}
+	if len(params) == 0 {
+		return "[%" + c.Name + "]"
+	}
+	return "[%" + c.Name + " " + strings.Join(params, ",") + "]"
+}